@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"poly/pkg/exchange"
+)
+
+// runAPIKey 通过钱包私钥 (L1 认证) 创建、派生或轮换 CLOB API 凭证
+//
+// 用法: go run . apikey [-create|-derive|-rotate] [-nonce N]
+// 签名方式见 loadSigner (keystore、助记词、远程签名或 POLY_PRIVATE_KEY), Funder 地址从 POLY_FUNDER 读取
+func runAPIKey(args []string) {
+	fs := flag.NewFlagSet("apikey", flag.ExitOnError)
+	create := fs.Bool("create", false, "强制创建新的凭证")
+	derive := fs.Bool("derive", false, "仅派生已存在的凭证")
+	rotate := fs.Bool("rotate", false, "用新 nonce 创建凭证并吊销当前凭证 (需要 POLY_API_KEY 等环境变量)")
+	nonce := fs.Int64("nonce", 0, "凭证 nonce")
+	fs.Parse(args)
+
+	ctx := context.Background()
+	s, err := loadSigner(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	client := exchange.NewPolymarketClientWithSigner(
+		os.Getenv("POLY_API_KEY"),
+		os.Getenv("POLY_API_SECRET"),
+		os.Getenv("POLY_PASSPHRASE"),
+		s,
+		os.Getenv("POLY_FUNDER"),
+	)
+
+	var creds *exchange.APICredentials
+	switch {
+	case *rotate:
+		creds, err = client.CreateAPIKey(ctx, *nonce)
+		if err != nil {
+			log.Fatalf("创建新凭证失败: %v", err)
+		}
+		// 旧凭证仍在 client 上, 先吊销再安装新凭证
+		if err := client.DeleteAPIKey(ctx); err != nil {
+			log.Fatalf("吊销旧凭证失败: %v", err)
+		}
+		client.SetCredentials(creds)
+	case *create:
+		creds, err = client.CreateAPIKey(ctx, *nonce)
+	case *derive:
+		creds, err = client.DeriveAPIKey(ctx, *nonce)
+	default:
+		creds, err = client.CreateOrDeriveAPIKey(ctx, *nonce)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("POLY_API_KEY=%s\n", creds.APIKey)
+	fmt.Printf("POLY_API_SECRET=%s\n", creds.Secret)
+	fmt.Printf("POLY_PASSPHRASE=%s\n", creds.Passphrase)
+}
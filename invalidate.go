@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"poly/pkg/exchange"
+	"poly/pkg/settlement"
+)
+
+// runInvalidate 在链上递增 Funder 在两个交易所合约的 nonce, 使此前签名的所有订单失效
+// (即使 CLOB 不可用或 API 凭证丢失也能生效)
+//
+// 用法: go run . invalidate -rpc <url> [-sigtype 0|1|2]
+// 运行中的机器人每 nonceRefresh 重新读取 nonce, 无需重启
+func runInvalidate(args []string) {
+	fs := flag.NewFlagSet("invalidate", flag.ExitOnError)
+	rpcURL := fs.String("rpc", os.Getenv("POLY_RPC_URL"), "Polygon RPC 地址")
+	sigType := fs.Uint("sigtype", 0, "签名类型: 0=EOA, 1=POLY_PROXY, 2=POLY_GNOSIS_SAFE")
+	timeout := fs.Duration("timeout", 5*time.Minute, "等待交易上链的期限")
+	fs.Parse(args)
+
+	if *rpcURL == "" {
+		log.Fatal("缺少 -rpc 或 POLY_RPC_URL")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	s, err := loadSigner(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	funder, err := exchange.FunderAddress(s.Address(), exchange.SignatureType(*sigType))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if configured := os.Getenv("POLY_FUNDER"); configured != "" && common.HexToAddress(configured) != funder {
+		log.Fatalf("POLY_FUNDER %s 与推导的 %s 地址 %s 不一致, 检查 -sigtype", configured, exchange.SignatureType(*sigType), funder.Hex())
+	}
+
+	chain, err := ethclient.DialContext(ctx, *rpcURL)
+	if err != nil {
+		log.Fatalf("连接 RPC 失败: %v", err)
+	}
+	defer chain.Close()
+	settler := settlement.NewSettler(chain, s)
+	settler.Funder = funder
+	settler.SignatureType = exchange.SignatureType(*sigType)
+
+	nonces, err := settler.IncrementNonce(ctx)
+	if err != nil {
+		log.Fatalf("递增 nonce 失败: %v", err)
+	}
+	fmt.Printf("Funder %s 的订单已全部失效, 新 nonce:\n", funder.Hex())
+	for _, addr := range settler.Exchanges() {
+		fmt.Printf("  %s: %s\n", addr.Hex(), nonces[addr])
+	}
+}
+
+// nonceRefresh 是运行中的机器人重新读取 nonce 的间隔, invalidate 递增 nonce 后新订单随之改用新值
+const nonceRefresh = time.Minute
+
+// loadNonces 读取 Funder 在各交易所合约的当前 nonce, 新订单以此签名
+func loadNonces(ctx context.Context, settler *settlement.Settler, client *exchange.PolymarketClient) error {
+	for _, addr := range settler.Exchanges() {
+		n, err := settler.ExchangeNonce(ctx, addr)
+		if err != nil {
+			return err
+		}
+		if old := client.Orders.Nonce(addr.Hex()); old.Cmp(n) != 0 {
+			log.Printf("交易所 %s 的订单 nonce: %s -> %s", addr.Hex(), old, n)
+		}
+		client.Orders.SetNonce(addr.Hex(), n)
+	}
+	return nil
+}
+
+// watchNonces 每隔 interval 刷新 nonce, 直到 ctx 取消
+func watchNonces(ctx context.Context, settler *settlement.Settler, client *exchange.PolymarketClient, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := loadNonces(ctx, settler, client); err != nil {
+				log.Printf("刷新订单 nonce 失败: %v", err)
+			}
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"time"
 
 	"poly/pkg/config"
@@ -10,6 +12,31 @@ import (
 )
 
 func main() {
+	// 子命令: apikey 管理 API 凭证, markets 搜索市场, setup 检查钱包授权, invalidate 链上作废全部订单, run 运行实盘, 默认运行模拟
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "apikey":
+			runAPIKey(os.Args[2:])
+			return
+		case "markets":
+			runMarkets(os.Args[2:])
+			return
+		case "setup":
+			runSetup(os.Args[2:])
+			return
+		case "invalidate":
+			runInvalidate(os.Args[2:])
+			return
+		case "run":
+			runLive(os.Args[2:])
+			return
+		}
+	}
+
+	runSimulation()
+}
+
+func runSimulation() {
 	fmt.Println("启动 Polymarket Smart Ape 策略机器人 (模拟模式)...")
 
 	// 1. 初始化配置
@@ -19,27 +46,28 @@ func main() {
 
 	// 2. 初始化模拟交易所
 	mockExc := exchange.NewMockExchange()
-	
+
 	// 3. 初始化机器人
 	bot := strategy.NewBot(cfg, mockExc)
+	ctx := context.Background()
 
 	// 4. 运行模拟循环
 	// 场景：市场开始平稳，突然 UP 价格暴跌，触发 Leg 1，然后价格稳定，触发 Leg 2
-	
+
 	fmt.Println(">>> 模拟开始: 初始价格 UP: 0.50, DOWN: 0.50")
 	mockExc.SetPrice(0.50, 0.50)
 
 	// 前 10 秒平稳
 	for i := 0; i < 10; i++ {
 		mockExc.AdvanceTime(1 * time.Second)
-		bot.RunTick()
+		bot.RunTick(ctx)
 	}
 
 	// 触发暴跌：UP 从 0.50 -> 0.30 (跌幅 40% > 15%)
 	fmt.Println("\n>>> 模拟暴跌事件! UP 0.50 -> 0.30")
 	mockExc.AdvanceTime(1 * time.Second)
 	mockExc.SetPrice(0.30, 0.55) // DOWN 稍微上涨但有滞后或价差
-	bot.RunTick() // 这里应该触发 Leg 1 买入 UP
+	bot.RunTick(ctx)             // 这里应该触发 Leg 1 买入 UP
 
 	// 此时 Leg 1 买入 UP @ 0.30
 	// 此时 DOWN 价格 0.55
@@ -51,7 +79,7 @@ func main() {
 
 	// 假设暴跌时 DOWN 涨得很快，导致 Sum > 0.95
 	// 例如 UP 0.30, DOWN 0.75 (Sum 1.05) -> 不买 Leg 2
-	
+
 	fmt.Println("\n>>> 模拟暴跌场景 2: DOWN 价格飙升导致无法立即对冲")
 	// 重置
 	bot.ResetCycle()
@@ -59,13 +87,13 @@ func main() {
 	// 填充历史 buffer
 	for i := 0; i < 5; i++ {
 		mockExc.AdvanceTime(1 * time.Second)
-		bot.RunTick()
+		bot.RunTick(ctx)
 	}
-	
+
 	fmt.Println(">>> 暴跌发生...")
 	mockExc.AdvanceTime(1 * time.Second)
 	mockExc.SetPrice(0.30, 0.75) // Sum = 1.05
-	bot.RunTick() // 触发 Leg 1
+	bot.RunTick(ctx)             // 触发 Leg 1
 
 	// 此时持有 UP @ 0.30
 	// 等待 DOWN 价格回落
@@ -74,20 +102,31 @@ func main() {
 	for {
 		steps++
 		mockExc.AdvanceTime(1 * time.Second)
-		
+
 		// 模拟 DOWN 价格缓慢下降
 		currentDown := mockExc.CurrentTicker.PriceDown
 		if currentDown > 0.60 {
-			mockExc.SetPrice(0.30, currentDown - 0.02)
+			mockExc.SetPrice(0.30, currentDown-0.02)
 		}
-		
+
 		fmt.Printf("Tick %d: UP=%.2f, DOWN=%.2f\n", steps, mockExc.CurrentTicker.PriceUp, mockExc.CurrentTicker.PriceDown)
-		bot.RunTick()
-		
+		bot.RunTick(ctx)
+
 		// 如果我们完成了，就退出
 		// (在真实代码中可以通过检查 Bot 状态，这里简单跑几步)
 		if steps > 10 {
 			break
 		}
 	}
+
+	// 汇总持仓与盈亏 (按当前价格估值)
+	fmt.Println("\n>>> 持仓汇总")
+	for _, pos := range bot.Portfolio().Positions() {
+		fmt.Printf("%s: %s 股, 均价 %s, 已实现 %s\n", pos.Side, pos.Shares, pos.AvgCost(), pos.Realized)
+	}
+	pnl := bot.Portfolio().MarketPnL(cfg.MarketID, mockExc.CurrentTicker)
+	fmt.Printf("已实现盈亏 %s, 未实现盈亏 %s\n", pnl.Realized, pnl.Unrealized)
+	for _, c := range bot.Cycles() {
+		fmt.Printf("周期 %d: 成本 %s, 回款 %s, 已结算 %v\n", c.ID, c.Cost, c.Payout, c.Settled)
+	}
 }
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"poly/pkg/exchange"
+)
+
+// marketConfig 是可直接写入 config.Config 的市场字段
+type marketConfig struct {
+	MarketID    string    `json:"market_id"`
+	TokenIDUp   string    `json:"token_id_up"`
+	TokenIDDown string    `json:"token_id_down"`
+	Question    string    `json:"question"`
+	Slug        string    `json:"slug"`
+	EndTime     time.Time `json:"end_time"`
+	TickSize    float64   `json:"tick_size"`
+	Liquidity   float64   `json:"liquidity"`
+}
+
+// runMarkets 通过 Gamma API 搜索可交易的市场, 按流动性排序
+//
+// 用法: go run . markets [-q 关键词] [-tag crypto] [-series btc-updown-15m] [-limit 20] [-json]
+func runMarkets(args []string) {
+	fs := flag.NewFlagSet("markets", flag.ExitOnError)
+	keyword := fs.String("q", "", "按问题或 slug 中的关键词过滤")
+	tag := fs.String("tag", "", "按标签 slug 过滤, 如 crypto")
+	series := fs.String("series", "", "按循环市场的 slug 前缀过滤, 如 btc-updown-15m")
+	limit := fs.Int("limit", 20, "最多列出的市场数")
+	asJSON := fs.Bool("json", false, "输出 JSON (market_id / token_id_up / token_id_down 可直接用于配置)")
+	fs.Parse(args)
+
+	markets, err := exchange.NewGammaClient().SearchMarkets(context.Background(), exchange.MarketQuery{
+		Keyword: *keyword,
+		Tag:     *tag,
+		Series:  *series,
+		Limit:   *limit,
+	})
+	if err != nil {
+		log.Fatalf("搜索市场失败: %v", err)
+	}
+
+	var rows []marketConfig
+	for _, gm := range markets {
+		m, err := gm.Market()
+		if err != nil {
+			continue // 只列出二元市场
+		}
+		rows = append(rows, marketConfig{
+			MarketID:    m.ID,
+			TokenIDUp:   m.TokenUp,
+			TokenIDDown: m.TokenDown,
+			Question:    gm.Question,
+			Slug:        gm.Slug,
+			EndTime:     gm.EndTime,
+			TickSize:    gm.TickSize,
+			Liquidity:   gm.Liquidity,
+		})
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(rows)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CONDITION ID\tUP TOKEN\tDOWN TOKEN\tEND\tTICK\tLIQUIDITY\tQUESTION")
+	for _, r := range rows {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%g\t%.0f\t%s\n",
+			r.MarketID, r.TokenIDUp, r.TokenIDDown, r.EndTime.Local().Format("2006-01-02 15:04"), r.TickSize, r.Liquidity, r.Question)
+	}
+	w.Flush()
+
+	if len(rows) == 0 {
+		fmt.Println("没有匹配的市场")
+	}
+}
@@ -12,18 +12,42 @@ type Config struct {
 	WindowMin time.Duration `json:"window_min"` // Time window for Leg 1 (e.g. 2 minutes)
 	FeeRate   float64       `json:"fee_rate"`   // Fee rate for simulation (e.g. 0.001)
 
+	// Execution
+	FillTimeout   time.Duration `json:"fill_timeout"`    // Cancel an unfilled leg order after this long
+	Leg1OrderType string        `json:"leg1_order_type"` // GTC, GTD, FOK or FAK
+	Leg2OrderType string        `json:"leg2_order_type"` // GTC, GTD, FOK or FAK
+	OrderTTL      time.Duration `json:"order_ttl"`       // Lifetime of GTD orders
+
+	// Settlement
+	RedeemInterval time.Duration `json:"redeem_interval"` // How often held markets are checked for resolution
+
+	// Deadlines of single exchange calls, unbounded when zero
+	RequestTimeout time.Duration `json:"request_timeout"` // Tickers, order lookups and cancels
+	OrderTimeout   time.Duration `json:"order_timeout"`   // Placements, including their recovery retries
+	SettleTimeout  time.Duration `json:"settle_timeout"`  // On-chain settlements, until reported overdue
+
 	// System
-	MarketID     string        `json:"market_id"` // The Market ID to trade
+	MarketID     string        `json:"market_id"`     // The Market (condition) ID to trade
+	TokenIDUp    string        `json:"token_id_up"`   // Outcome tokens of MarketID; looked up when empty
+	TokenIDDown  string        `json:"token_id_down"` // (see `go run . markets`)
 	PollInterval time.Duration `json:"poll_interval"`
 }
 
 func DefaultConfig() *Config {
 	return &Config{
-		Shares:       20.0,
-		SumTarget:    0.95,
-		MovePct:      0.15,
-		WindowMin:    2 * time.Minute,
-		FeeRate:      0.0, // Polymarket rebate?
-		PollInterval: 1 * time.Second,
+		Shares:         20.0,
+		SumTarget:      0.95,
+		MovePct:        0.15,
+		WindowMin:      2 * time.Minute,
+		FeeRate:        0.0, // Polymarket rebate?
+		FillTimeout:    10 * time.Second,
+		Leg1OrderType:  "GTC", // Set FAK to take what the dump offers and never rest
+		Leg2OrderType:  "GTC", // The hedge may rest until FillTimeout
+		OrderTTL:       2 * time.Minute,
+		RedeemInterval: time.Minute,
+		RequestTimeout: 5 * time.Second,
+		OrderTimeout:   15 * time.Second,
+		SettleTimeout:  2 * time.Minute,
+		PollInterval:   1 * time.Second,
 	}
 }
@@ -0,0 +1,194 @@
+package exchange
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DataAPIURL serves positions and activity by wallet address
+const DataAPIURL = "https://data-api.polymarket.com"
+
+type makerOrder struct {
+	OrderID       string `json:"order_id"`
+	AssetID       string `json:"asset_id"`
+	Owner         string `json:"owner"`
+	MatchedAmount string `json:"matched_amount"`
+	Price         string `json:"price"`
+	Side          string `json:"side"`
+}
+
+// clobTrade is a trade as reported by GET /data/trades and the user channel
+type clobTrade struct {
+	ID           string       `json:"id"`
+	Market       string       `json:"market"`
+	AssetID      string       `json:"asset_id"`
+	Owner        string       `json:"owner"` // API key owning the taker order
+	Side         string       `json:"side"`
+	Price        string       `json:"price"`
+	Size         string       `json:"size"`
+	Status       string       `json:"status"`
+	MatchTime    string       `json:"match_time"`
+	TakerOrderID string       `json:"taker_order_id"`
+	MakerOrders  []makerOrder `json:"maker_orders"`
+}
+
+// fill is the part of a trade matched against one order of ours
+type fill struct {
+	orderID   string
+	assetID   string
+	direction Direction
+	price     float64
+	size      float64
+}
+
+// ownFills returns the fills of the orders owned by apiKey. We may be the taker,
+// one or more of the makers, or both.
+func (t *clobTrade) ownFills(apiKey string) []fill {
+	var fills []fill
+	add := func(orderID, assetID, side, price, size string) {
+		p, _ := strconv.ParseFloat(price, 64)
+		q, _ := strconv.ParseFloat(size, 64)
+		fills = append(fills, fill{orderID, assetID, Direction(strings.ToUpper(side)), p, q})
+	}
+
+	if t.Owner == apiKey {
+		add(t.TakerOrderID, t.AssetID, t.Side, t.Price, t.Size)
+	}
+	for _, mo := range t.MakerOrders {
+		if mo.Owner == apiKey {
+			add(mo.OrderID, mo.AssetID, mo.Side, mo.Price, mo.MatchedAmount)
+		}
+	}
+	return fills
+}
+
+// GetTrades lists the account's fills in a market (GET /data/trades), one per order
+// of ours involved in each trade
+func (c *PolymarketClient) GetTrades(ctx context.Context, marketID string) ([]*Trade, error) {
+	m, err := c.Market(ctx, marketID)
+	if err != nil {
+		return nil, err
+	}
+
+	var trades []*Trade
+	cursor := ""
+	for cursor != endCursor {
+		path := "/data/trades?market=" + url.QueryEscape(marketID)
+		if cursor != "" {
+			path += "&next_cursor=" + url.QueryEscape(cursor)
+		}
+
+		var page struct {
+			Data       []clobTrade `json:"data"`
+			NextCursor string      `json:"next_cursor"`
+		}
+		if err := c.doL2(ctx, "GET", path, nil, &page); err != nil {
+			return nil, err
+		}
+		for i := range page.Data {
+			t := &page.Data[i]
+			ts := parseEventTime(t.MatchTime)
+			for _, f := range t.ownFills(c.APIKey) {
+				side := SideUp
+				if f.assetID == m.TokenDown {
+					side = SideDown
+				}
+				trades = append(trades, &Trade{
+					ID:        t.ID,
+					MarketID:  t.Market,
+					OrderID:   f.orderID,
+					Side:      side,
+					Direction: f.direction,
+					Price:     f.price,
+					Size:      f.size,
+					Status:    TradeStatus(strings.ToUpper(t.Status)),
+					Timestamp: ts,
+				})
+			}
+		}
+
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	return trades, nil
+}
+
+// dataPosition is a holding as reported by the data API
+type dataPosition struct {
+	Asset       string  `json:"asset"`
+	ConditionID string  `json:"conditionId"`
+	Size        float64 `json:"size"`
+	AvgPrice    float64 `json:"avgPrice"`
+	Outcome     string  `json:"outcome"`
+}
+
+// positionsPage is the largest page the data API serves
+const positionsPage = 500
+
+// GetPositions lists the funder's outcome token holdings from the data API;
+// an empty marketID lists every market
+func (c *PolymarketClient) GetPositions(ctx context.Context, marketID string) ([]*Position, error) {
+	dataURL := c.DataURL
+	if dataURL == "" {
+		dataURL = DataAPIURL
+	}
+	query := url.Values{
+		"user":          {strings.ToLower(c.Funder.Hex())},
+		"sizeThreshold": {"0"},
+		"limit":         {strconv.Itoa(positionsPage)},
+	}
+	if marketID != "" {
+		query.Set("market", marketID)
+	}
+
+	var positions []*Position
+	for offset := 0; ; offset += positionsPage {
+		query.Set("offset", strconv.Itoa(offset))
+		req, err := http.NewRequestWithContext(ctx, "GET", dataURL+"/positions?"+query.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+		var page []dataPosition
+		if err := c.do(req, &page); err != nil {
+			return nil, err
+		}
+
+		for _, p := range page {
+			if p.Size <= 0 {
+				continue
+			}
+			positions = append(positions, &Position{
+				MarketID: p.ConditionID,
+				Side:     c.positionSide(ctx, &p),
+				TokenID:  p.Asset,
+				Size:     p.Size,
+				AvgPrice: p.AvgPrice,
+			})
+		}
+		if len(page) < positionsPage {
+			return positions, nil
+		}
+	}
+}
+
+// positionSide resolves the outcome of a holding by its token, falling back to its name
+func (c *PolymarketClient) positionSide(ctx context.Context, p *dataPosition) Side {
+	if m, err := c.Market(ctx, p.ConditionID); err == nil {
+		if p.Asset == m.TokenDown {
+			return SideDown
+		}
+		if p.Asset == m.TokenUp {
+			return SideUp
+		}
+	}
+	switch strings.ToLower(p.Outcome) {
+	case "no", "down":
+		return SideDown
+	}
+	return SideUp
+}
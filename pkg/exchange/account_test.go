@@ -0,0 +1,80 @@
+package exchange
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGetTrades(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/data/trades" || r.URL.Query().Get("market") != "0xcond" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Query().Get("next_cursor") == "" {
+			// We took UP shares from someone else's ask
+			w.Write([]byte(`{"data": [{"id": "t1", "market": "0xcond", "asset_id": "111", "owner": "test-key",
+				"side": "BUY", "price": "0.40", "size": "10", "status": "CONFIRMED", "match_time": "1760000400",
+				"taker_order_id": "0xa", "maker_orders": [{"order_id": "0xm", "owner": "other", "asset_id": "111",
+				"matched_amount": "10", "price": "0.40", "side": "SELL"}]}], "next_cursor": "MQ=="}`))
+			return
+		}
+		// Our resting DOWN bid was hit
+		w.Write([]byte(`{"data": [{"id": "t2", "market": "0xcond", "asset_id": "222", "owner": "other",
+			"side": "SELL", "price": "0.55", "size": "5", "status": "MATCHED", "match_time": "1760000460",
+			"taker_order_id": "0xt", "maker_orders": [{"order_id": "0xb", "owner": "test-key", "asset_id": "222",
+			"matched_amount": "5", "price": "0.55", "side": "BUY"}]}], "next_cursor": "LTE="}`))
+	})
+
+	trades, err := c.GetTrades(ctx, "0xcond")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trades) != 2 {
+		t.Fatalf("expected 2 fills, got %d", len(trades))
+	}
+	up, down := trades[0], trades[1]
+	if up.OrderID != "0xa" || up.Side != SideUp || up.Direction != DirectionBuy || up.Size != 10 || up.Status != TradeConfirmed {
+		t.Errorf("unexpected taker fill %+v", up)
+	}
+	if down.OrderID != "0xb" || down.Side != SideDown || down.Direction != DirectionBuy || down.Price != 0.55 || down.Timestamp.Unix() != 1760000460 {
+		t.Errorf("unexpected maker fill %+v", down)
+	}
+}
+
+func TestGetPositions(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, http.NotFound)
+	data := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/positions" || q.Get("user") != strings.ToLower(c.Funder.Hex()) {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`[
+			{"asset": "222", "conditionId": "0xcond", "size": 12.5, "avgPrice": 0.42, "outcome": "Down"},
+			{"asset": "999", "conditionId": "0xother", "size": 3, "avgPrice": 0.1, "outcome": "No"},
+			{"asset": "111", "conditionId": "0xcond", "size": 0, "avgPrice": 0.5, "outcome": "Up"}]`))
+	}))
+	t.Cleanup(data.Close)
+	c.DataURL = data.URL
+
+	positions, err := c.GetPositions(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(positions) != 2 {
+		t.Fatalf("expected the 2 open positions, got %d", len(positions))
+	}
+	if p := positions[0]; p.MarketID != "0xcond" || p.Side != SideDown || p.Size != 12.5 || p.AvgPrice != 0.42 {
+		t.Errorf("unexpected position %+v", p)
+	}
+	// Unknown markets fall back to the outcome name
+	if p := positions[1]; p.Side != SideDown {
+		t.Errorf("expected a NO holding to map to DOWN, got %+v", p)
+	}
+}
@@ -0,0 +1,199 @@
+package exchange
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Amount is a fixed-point quantity with 6 decimals, the precision of both USDC and
+// the conditional tokens. 1.0 is stored as 1,000,000 raw units.
+type Amount int64
+
+const (
+	AmountDecimals = 6
+	amountScale    = 1_000_000
+
+	// sizeDecimals is the share precision accepted by the CLOB
+	sizeDecimals = 2
+	// minTickSize is the finest price increment of any market (0.0001)
+	minTickSize Amount = 100
+)
+
+// RoundingMode selects how a value that does not fit the precision is rounded
+type RoundingMode int
+
+const (
+	RoundDown   RoundingMode = iota // Toward zero
+	RoundUp                         // Away from zero
+	RoundHalfUp                     // To nearest, ties away from zero
+)
+
+// AmountFromFloat converts a float to the nearest raw unit
+func AmountFromFloat(f float64) Amount {
+	return Amount(math.Round(f * amountScale))
+}
+
+// ParseAmount parses a decimal string such as "0.45" exactly.
+// Values with more than 6 decimals are rejected rather than silently rounded.
+func ParseAmount(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if intPart == "" && fracPart == "" {
+		return 0, fmt.Errorf("invalid amount: %q", s)
+	}
+	if len(fracPart) > AmountDecimals {
+		return 0, fmt.Errorf("amount %q exceeds %d decimals", s, AmountDecimals)
+	}
+	if intPart == "" {
+		intPart = "0"
+	}
+	fracPart += strings.Repeat("0", AmountDecimals-len(fracPart))
+
+	whole, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount: %q", s)
+	}
+	frac, err := strconv.ParseInt(fracPart, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount: %q", s)
+	}
+	if whole > (math.MaxInt64-frac)/amountScale {
+		return 0, fmt.Errorf("amount %q out of range", s)
+	}
+
+	a := Amount(whole*amountScale + frac)
+	if neg {
+		a = -a
+	}
+	return a, nil
+}
+
+// Float64 returns the approximate float value, for display and ratios only
+func (a Amount) Float64() float64 {
+	return float64(a) / amountScale
+}
+
+// Raw returns the on-chain integer representation used in signed orders
+func (a Amount) Raw() *big.Int {
+	return big.NewInt(int64(a))
+}
+
+// String formats the amount without trailing zeros (e.g. "0.45", "10")
+func (a Amount) String() string {
+	sign := ""
+	v := int64(a)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+
+	s := fmt.Sprintf("%s%d", sign, v/amountScale)
+	if frac := v % amountScale; frac != 0 {
+		s += "." + strings.TrimRight(fmt.Sprintf("%06d", frac), "0")
+	}
+	return s
+}
+
+func (a Amount) Add(b Amount) Amount { return a + b }
+func (a Amount) Sub(b Amount) Amount { return a - b }
+
+// Mul multiplies two amounts (e.g. price * size), rounding the result to 6 decimals
+func (a Amount) Mul(b Amount, mode RoundingMode) Amount {
+	n := new(big.Int).Mul(big.NewInt(int64(a)), big.NewInt(int64(b)))
+	return divRound(n, big.NewInt(amountScale), mode)
+}
+
+// Quo divides two amounts (e.g. cost / size), rounding the result to 6 decimals
+func (a Amount) Quo(b Amount, mode RoundingMode) Amount {
+	if b == 0 {
+		return 0
+	}
+	n := new(big.Int).Mul(big.NewInt(int64(a)), big.NewInt(amountScale))
+	return divRound(n, big.NewInt(int64(b)), mode)
+}
+
+// Round rounds the amount to the given number of decimals (0-6)
+func (a Amount) Round(decimals int, mode RoundingMode) Amount {
+	if decimals >= AmountDecimals {
+		return a
+	}
+	unit := int64(math.Pow10(AmountDecimals - decimals))
+	return a.RoundTo(Amount(unit), mode)
+}
+
+// RoundTo rounds the amount to a multiple of step (e.g. a tick size)
+func (a Amount) RoundTo(step Amount, mode RoundingMode) Amount {
+	if step <= 0 {
+		return a
+	}
+	return divRound(big.NewInt(int64(a)), big.NewInt(int64(step)), mode) * step
+}
+
+// divRound computes n/d with the given rounding mode
+func divRound(n, d *big.Int, mode RoundingMode) Amount {
+	q, r := new(big.Int).QuoRem(n, d, new(big.Int))
+	if r.Sign() != 0 {
+		sign := int64(n.Sign() * d.Sign())
+		switch mode {
+		case RoundUp:
+			q.Add(q, big.NewInt(sign))
+		case RoundHalfUp:
+			twice := new(big.Int).Abs(r)
+			twice.Lsh(twice, 1)
+			if twice.Cmp(new(big.Int).Abs(d)) >= 0 {
+				q.Add(q, big.NewInt(sign))
+			}
+		}
+	}
+	return Amount(q.Int64())
+}
+
+// limitPrice rounds a price to the nearest tick, the limit an order is signed at
+func limitPrice(price float64, tick Amount) Amount {
+	if tick <= 0 {
+		tick = minTickSize
+	}
+	return AmountFromFloat(price).RoundTo(tick, RoundHalfUp)
+}
+
+// orderAmounts computes the signed maker/taker amounts of an order. Sizes are
+// truncated to the share precision and prices rounded to the nearest tick, as the
+// official client does; the USDC side is then rounded so that the implied price is
+// never worse than the limit.
+func orderAmounts(dir Direction, orderType OrderType, price, size float64, tick Amount) (maker, taker Amount) {
+	p := limitPrice(price, tick)
+	s := AmountFromFloat(size).Round(sizeDecimals, RoundDown)
+	market := orderType == OrderTypeFOK || orderType == OrderTypeFAK
+
+	if dir == DirectionSell {
+		return sellAmounts(p, s, market)
+	}
+	return buyAmounts(p, s, market)
+}
+
+// buyAmounts: maker = USDC paid, rounded down; taker = shares received.
+// Market orders only accept 2 decimals for the USDC and 4 for the shares, so the
+// cost is truncated to cents and the shares it buys at the limit are truncated too.
+func buyAmounts(p, s Amount, market bool) (maker, taker Amount) {
+	if market {
+		maker = p.Mul(s, RoundDown).Round(2, RoundDown)
+		return maker, maker.Quo(p, RoundDown).Round(4, RoundDown)
+	}
+	return p.Mul(s, RoundDown), s
+}
+
+// sellAmounts: maker = shares sold; taker = USDC received, rounded up.
+// Market orders only accept 4 decimals for the USDC side.
+func sellAmounts(p, s Amount, market bool) (maker, taker Amount) {
+	taker = p.Mul(s, RoundUp)
+	if market {
+		taker = taker.Round(4, RoundUp)
+	}
+	return s, taker
+}
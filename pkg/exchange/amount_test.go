@@ -0,0 +1,117 @@
+package exchange
+
+import "testing"
+
+func TestParseAmount(t *testing.T) {
+	cases := map[string]Amount{
+		"0.45":     450000,
+		"10":       10000000,
+		".5":       500000,
+		"-1.25":    -1250000,
+		"0.000001": 1,
+	}
+	for in, want := range cases {
+		got, err := ParseAmount(in)
+		if err != nil {
+			t.Errorf("ParseAmount(%q): %v", in, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("ParseAmount(%q) = %d, want %d", in, got, want)
+		}
+	}
+
+	for _, in := range []string{"", "abc", "0.0000001", "1.2.3"} {
+		if _, err := ParseAmount(in); err == nil {
+			t.Errorf("ParseAmount(%q) should fail", in)
+		}
+	}
+}
+
+func TestAmountRounding(t *testing.T) {
+	p := AmountFromFloat(0.333333)
+	s := AmountFromFloat(3)
+
+	if got := p.Mul(s, RoundDown); got != 999999 {
+		t.Errorf("Mul RoundDown = %d", got)
+	}
+	if got := AmountFromFloat(1).Quo(s, RoundDown); got != 333333 {
+		t.Errorf("Quo RoundDown = %d", got)
+	}
+	if got := AmountFromFloat(1).Quo(s, RoundUp); got != 333334 {
+		t.Errorf("Quo RoundUp = %d", got)
+	}
+	if got := AmountFromFloat(0.125).Round(2, RoundHalfUp); got != 130000 {
+		t.Errorf("Round HalfUp = %s", got)
+	}
+	if got := AmountFromFloat(0.129).Round(2, RoundDown); got != 120000 {
+		t.Errorf("Round Down = %s", got)
+	}
+	if got := AmountFromFloat(0.537).RoundTo(AmountFromFloat(0.01), RoundHalfUp); got.String() != "0.54" {
+		t.Errorf("RoundTo tick = %s", got)
+	}
+	if got := AmountFromFloat(-0.5).String(); got != "-0.5" {
+		t.Errorf("String = %s", got)
+	}
+}
+
+func TestBuyAmounts(t *testing.T) {
+	// int64(0.3 * 1e6 * 10.29) truncates to 3086999
+	maker, taker := orderAmounts(DirectionBuy, OrderTypeGTC, 0.3, 10.29, 0)
+	if maker != 3087000 || taker != 10290000 {
+		t.Errorf("buy(0.3, 10.29, 0) = %d, %d", maker, taker)
+	}
+
+	// Size is truncated to 2 decimals before computing the cost
+	maker, taker = orderAmounts(DirectionBuy, OrderTypeGTC, 0.57, 10.129, 0)
+	if taker != 10120000 || maker != 5768400 {
+		t.Errorf("buy(0.57, 10.129, 0) = %d, %d", maker, taker)
+	}
+
+	// Price is rounded to the market tick
+	maker, _ = orderAmounts(DirectionBuy, OrderTypeGTC, 0.537, 10, AmountFromFloat(0.01))
+	if maker != 5400000 {
+		t.Errorf("buy(0.537, 10, 0.01) maker = %d", maker)
+	}
+
+	// Market orders: USDC truncated to cents, shares to 4 decimals
+	maker, taker = orderAmounts(DirectionBuy, OrderTypeFOK, 0.33, 10.07, AmountFromFloat(0.01))
+	if maker != 3320000 || taker != 10060600 {
+		t.Errorf("market buy(0.33, 10.07) = %d, %d", maker, taker)
+	}
+}
+
+func TestSellAmounts(t *testing.T) {
+	// Shares are the maker side, the USDC received the taker side
+	maker, taker := orderAmounts(DirectionSell, OrderTypeGTC, 0.3333, 3, 0)
+	if maker != 3000000 || taker != 999900 {
+		t.Errorf("sell(0.3333, 3) = %d, %d", maker, taker)
+	}
+
+	// Market sells round the USDC up to 4 decimals
+	maker, taker = orderAmounts(DirectionSell, OrderTypeFAK, 0.333, 10.07, AmountFromFloat(0.001))
+	if maker != 10070000 || taker != 3353400 {
+		t.Errorf("market sell(0.333, 10.07) = %d, %d", maker, taker)
+	}
+}
+
+func TestOrderCost(t *testing.T) {
+	// 0.3333 does not survive float price x size exactly; the signed amounts do
+	maker, taker := orderAmounts(DirectionBuy, OrderTypeGTC, 0.3333, 3, 0)
+	o := &Order{Direction: DirectionBuy, Price: 0.3333, Size: 3, MakerAmount: maker, TakerAmount: taker}
+	if o.Cost() != 999900 {
+		t.Errorf("unfilled cost = %d, want the signed 999900", o.Cost())
+	}
+
+	// A partial fill costs its share of the signed USDC
+	o.SizeMatched = 1
+	if o.Cost() != 333300 {
+		t.Errorf("partial cost = %d, want 333300", o.Cost())
+	}
+
+	// A reported fill value wins, e.g. a fill below the limit
+	o.MatchedCost = 330000
+	if o.Cost() != 330000 {
+		t.Errorf("reported cost = %d, want 330000", o.Cost())
+	}
+}
@@ -0,0 +1,203 @@
+package exchange
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// L2 authentication headers required by the private CLOB endpoints
+const (
+	headerAddress    = "POLY_ADDRESS"
+	headerSignature  = "POLY_SIGNATURE"
+	headerTimestamp  = "POLY_TIMESTAMP"
+	headerAPIKey     = "POLY_API_KEY"
+	headerPassphrase = "POLY_PASSPHRASE"
+	headerNonce      = "POLY_NONCE"
+)
+
+// clobAuthMessage is the fixed statement signed in the ClobAuth EIP-712 message
+const clobAuthMessage = "This message attests that I control the given wallet"
+
+// APICredentials are the L2 credentials issued by the CLOB for a wallet
+type APICredentials struct {
+	APIKey     string `json:"apiKey"`
+	Secret     string `json:"secret"`
+	Passphrase string `json:"passphrase"`
+}
+
+// buildHMACSignature signs timestamp+method+path+body with the base64 encoded API secret,
+// returning the url-safe base64 digest expected in POLY_SIGNATURE.
+func buildHMACSignature(secret, timestamp, method, requestPath string, body []byte) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", fmt.Errorf("invalid api secret: %v", err)
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(timestamp + method + requestPath))
+	mac.Write(body)
+
+	return base64.URLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// decodeSecret accepts both url-safe and standard base64 secrets
+func decodeSecret(secret string) ([]byte, error) {
+	if key, err := base64.URLEncoding.DecodeString(secret); err == nil {
+		return key, nil
+	}
+	return base64.StdEncoding.DecodeString(secret)
+}
+
+// addL2Headers attaches the API key credentials and the HMAC signature of the request
+func (c *PolymarketClient) addL2Headers(req *http.Request, requestPath string, body []byte) error {
+	if c.APIKey == "" || c.APISecret == "" || c.Passphrase == "" {
+		return fmt.Errorf("missing api credentials")
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	sig, err := buildHMACSignature(c.APISecret, timestamp, req.Method, requestPath, body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set(headerAddress, c.signerAddress().Hex())
+	req.Header.Set(headerSignature, sig)
+	req.Header.Set(headerTimestamp, timestamp)
+	req.Header.Set(headerAPIKey, c.APIKey)
+	req.Header.Set(headerPassphrase, c.Passphrase)
+	return nil
+}
+
+// signerAddress is the EOA signing for the client
+func (c *PolymarketClient) signerAddress() common.Address {
+	return c.Signer.Address()
+}
+
+// CreateAPIKey registers a new set of API credentials for the wallet (POST /auth/api-key)
+func (c *PolymarketClient) CreateAPIKey(ctx context.Context, nonce int64) (*APICredentials, error) {
+	return c.requestAPIKey(ctx, "POST", "/auth/api-key", nonce)
+}
+
+// DeriveAPIKey returns the existing credentials created with the given nonce (GET /auth/derive-api-key)
+func (c *PolymarketClient) DeriveAPIKey(ctx context.Context, nonce int64) (*APICredentials, error) {
+	return c.requestAPIKey(ctx, "GET", "/auth/derive-api-key", nonce)
+}
+
+// CreateOrDeriveAPIKey creates credentials for the nonce, falling back to deriving
+// them when they already exist, and installs them on the client. Any other
+// failure, e.g. a network error or a rejected signature, is returned as is.
+func (c *PolymarketClient) CreateOrDeriveAPIKey(ctx context.Context, nonce int64) (*APICredentials, error) {
+	creds, err := c.CreateAPIKey(ctx, nonce)
+	if isKeyExists(err) {
+		creds, err = c.DeriveAPIKey(ctx, nonce)
+		if err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	c.SetCredentials(creds)
+	return creds, nil
+}
+
+// isKeyExists reports whether the CLOB refused to create credentials because the
+// nonce already has some: it answers 400 "Could not create api key"
+func isKeyExists(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		return false
+	}
+	msg := strings.ToLower(apiErr.Message)
+	return strings.Contains(msg, "could not create api key") || strings.Contains(msg, "already exists")
+}
+
+// DeleteAPIKey revokes the credentials currently installed on the client
+func (c *PolymarketClient) DeleteAPIKey(ctx context.Context) error {
+	return c.doL2(ctx, "DELETE", "/auth/api-key", nil, nil)
+}
+
+// SetCredentials installs L2 credentials on the client
+func (c *PolymarketClient) SetCredentials(creds *APICredentials) {
+	c.APIKey = creds.APIKey
+	c.APISecret = creds.Secret
+	c.Passphrase = creds.Passphrase
+}
+
+func (c *PolymarketClient) requestAPIKey(ctx context.Context, method, path string, nonce int64) (*APICredentials, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.addL1Headers(req, nonce); err != nil {
+		return nil, err
+	}
+
+	var creds APICredentials
+	if err := c.do(req, &creds); err != nil {
+		return nil, err
+	}
+	if creds.APIKey == "" {
+		return nil, fmt.Errorf("empty api key in response")
+	}
+	return &creds, nil
+}
+
+// addL1Headers proves control of the wallet by signing the ClobAuth message
+func (c *PolymarketClient) addL1Headers(req *http.Request, nonce int64) error {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	sig, err := c.signTypedData(req.Context(), c.clobAuthTypedData(timestamp, nonce))
+	if err != nil {
+		return fmt.Errorf("signing failed: %w", err)
+	}
+
+	req.Header.Set(headerAddress, c.signerAddress().Hex())
+	req.Header.Set(headerSignature, hexutil.Encode(sig))
+	req.Header.Set(headerTimestamp, timestamp)
+	req.Header.Set(headerNonce, strconv.FormatInt(nonce, 10))
+	return nil
+}
+
+func (c *PolymarketClient) clobAuthTypedData(timestamp string, nonce int64) apitypes.TypedData {
+	return apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+				{Name: "version", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+			},
+			"ClobAuth": {
+				{Name: "address", Type: "address"},
+				{Name: "timestamp", Type: "string"},
+				{Name: "nonce", Type: "uint256"},
+				{Name: "message", Type: "string"},
+			},
+		},
+		PrimaryType: "ClobAuth",
+		Domain: apitypes.TypedDataDomain{
+			Name:    "ClobAuthDomain",
+			Version: "1",
+			ChainId: math.NewHexOrDecimal256(c.ChainID),
+		},
+		Message: apitypes.TypedDataMessage{
+			"address":   c.signerAddress().Hex(),
+			"timestamp": timestamp,
+			"nonce":     strconv.FormatInt(nonce, 10),
+			"message":   clobAuthMessage,
+		},
+	}
+}
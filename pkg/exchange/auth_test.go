@@ -0,0 +1,103 @@
+package exchange
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"poly/pkg/signer"
+)
+
+func TestBuildHMACSignature(t *testing.T) {
+	sig, err := buildHMACSignature(testSecret, "1000000", "POST", "/order", []byte(`{"a":1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := buildHMACSignature(testSecret, "1000000", "POST", "/order", []byte(`{"a":1}`))
+	if sig != again {
+		t.Errorf("signature is not deterministic: %s vs %s", sig, again)
+	}
+
+	other, _ := buildHMACSignature(testSecret, "1000001", "POST", "/order", []byte(`{"a":1}`))
+	if sig == other {
+		t.Error("signature should depend on the timestamp")
+	}
+
+	if _, err := buildHMACSignature("!!not base64!!", "1", "GET", "/", nil); err == nil {
+		t.Error("expected error for invalid secret")
+	}
+}
+
+func TestCreateOrDeriveAPIKey(t *testing.T) {
+	ctx := context.Background()
+	var c *PolymarketClient
+	c = newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		// Recover the signer from the ClobAuth signature
+		nonce, _ := strconv.ParseInt(r.Header.Get(headerNonce), 10, 64)
+		hash, err := signer.TypedDataHash(c.clobAuthTypedData(r.Header.Get(headerTimestamp), nonce))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig, err := hexutil.Decode(r.Header.Get(headerSignature))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig[64] -= 27
+		pub, err := crypto.SigToPub(hash, sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if crypto.PubkeyToAddress(*pub).Hex() != r.Header.Get(headerAddress) {
+			t.Errorf("signature does not match POLY_ADDRESS")
+		}
+
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/auth/api-key":
+			// Key already exists for this nonce
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"Could not create api key"}`))
+		case r.Method == "GET" && r.URL.Path == "/auth/derive-api-key":
+			w.Write([]byte(`{"apiKey":"derived-key","secret":"derived-secret","passphrase":"derived-pass"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+	c.APIKey, c.APISecret, c.Passphrase = "", "", ""
+
+	creds, err := c.CreateOrDeriveAPIKey(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.APIKey != "derived-key" {
+		t.Errorf("unexpected credentials %+v", creds)
+	}
+	if c.APIKey != "derived-key" || c.APISecret != "derived-secret" || c.Passphrase != "derived-pass" {
+		t.Errorf("credentials not installed on client")
+	}
+}
+
+func TestCreateOrDeriveAPIKeyOnlyDerivesExistingKeys(t *testing.T) {
+	for _, status := range []int{http.StatusUnauthorized, http.StatusInternalServerError} {
+		derived := false
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/auth/derive-api-key" {
+				derived = true
+				w.Write([]byte(`{"apiKey":"derived-key","secret":"derived-secret","passphrase":"derived-pass"}`))
+				return
+			}
+			w.WriteHeader(status)
+			w.Write([]byte(`{"error":"something went wrong"}`))
+		})
+
+		if _, err := c.CreateOrDeriveAPIKey(context.Background(), 0); err == nil {
+			t.Errorf("status %d: expected the create failure to be returned", status)
+		}
+		if derived {
+			t.Errorf("status %d: derived credentials although the key may not exist", status)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// AssetType selects the collateral (USDC) or an outcome token in balance queries
+type AssetType string
+
+const (
+	AssetCollateral  AssetType = "COLLATERAL"
+	AssetConditional AssetType = "CONDITIONAL"
+)
+
+// Balance is what the funder holds of an asset and how much of it the exchange
+// contract of a market has been approved to move
+type Balance struct {
+	Asset     AssetType
+	TokenID   string // Outcome token, empty for collateral
+	Balance   Amount
+	Allowance Amount // Capped at MaxAmount; approvals are usually unlimited
+}
+
+// MaxAmount is the largest representable Amount
+const MaxAmount = Amount(math.MaxInt64)
+
+// Available returns what an order can actually spend: the balance, up to the allowance
+func (b *Balance) Available() Amount {
+	if b.Allowance < b.Balance {
+		return b.Allowance
+	}
+	return b.Balance
+}
+
+// balanceResponse is the reply of GET /balance-allowance. Older deployments report a
+// single allowance, newer ones one per spender contract.
+type balanceResponse struct {
+	Balance    string            `json:"balance"`
+	Allowance  string            `json:"allowance"`
+	Allowances map[string]string `json:"allowances"`
+}
+
+// parseRawAmount parses an integer count of raw units, saturating at MaxAmount
+func parseRawAmount(s string) (Amount, error) {
+	if s == "" {
+		return 0, nil
+	}
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok || v.Sign() < 0 {
+		return 0, fmt.Errorf("invalid raw amount: %q", s)
+	}
+	if !v.IsInt64() {
+		return MaxAmount, nil
+	}
+	return Amount(v.Int64()), nil
+}
+
+// GetCollateralBalance returns the funder's USDC and its allowance for the market's exchange contract
+func (c *PolymarketClient) GetCollateralBalance(ctx context.Context, marketID string) (*Balance, error) {
+	return c.balance(ctx, marketID, AssetCollateral, "")
+}
+
+// GetTokenBalance returns the funder's shares of a side's outcome token and their allowance
+func (c *PolymarketClient) GetTokenBalance(ctx context.Context, marketID string, side Side) (*Balance, error) {
+	m, err := c.Market(ctx, marketID)
+	if err != nil {
+		return nil, err
+	}
+	return c.balance(ctx, marketID, AssetConditional, m.TokenID(side))
+}
+
+// balance queries GET /balance-allowance as seen by the CLOB for the funder
+func (c *PolymarketClient) balance(ctx context.Context, marketID string, asset AssetType, tokenID string) (*Balance, error) {
+	info, err := c.GetMarketInfo(ctx, marketID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{
+		"asset_type":     {string(asset)},
+		"signature_type": {strconv.Itoa(int(c.SignatureType))},
+	}
+	if tokenID != "" {
+		query.Set("token_id", tokenID)
+	}
+	var resp balanceResponse
+	if err := c.doL2(ctx, "GET", "/balance-allowance?"+query.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+
+	b := &Balance{Asset: asset, TokenID: tokenID}
+	if b.Balance, err = parseRawAmount(resp.Balance); err != nil {
+		return nil, err
+	}
+	allowance := resp.Allowance
+	for spender, v := range resp.Allowances {
+		if strings.EqualFold(spender, info.ExchangeAddress()) {
+			allowance = v
+		}
+	}
+	if b.Allowance, err = parseRawAmount(allowance); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
@@ -0,0 +1,54 @@
+package exchange
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestGetBalances(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/balance-allowance" || r.Header.Get("POLY_API_KEY") != "test-key" {
+			http.NotFound(w, r)
+			return
+		}
+		q := r.URL.Query()
+		if q.Get("signature_type") != "0" {
+			t.Errorf("unexpected signature type %q", q.Get("signature_type"))
+		}
+		switch q.Get("asset_type") {
+		case "COLLATERAL":
+			w.Write([]byte(`{"balance": "12500000", "allowances": {
+				"0x4bfb41d5b3570defd03c39a9a4d8de6bd8b8982e": "115792089237316195423570985008687907853269984665640564039457584007913129639935",
+				"0xC5d563A36AE78145C45a50134d48A1215220f80a": "0"}}`))
+		case "CONDITIONAL":
+			if q.Get("token_id") != "222" {
+				t.Errorf("expected the DOWN token, got %q", q.Get("token_id"))
+			}
+			w.Write([]byte(`{"balance": "3000000", "allowance": "1000000"}`))
+		}
+	})
+
+	usdc, err := c.GetCollateralBalance(ctx, "0xcond")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usdc.Balance != AmountFromFloat(12.5) || usdc.Allowance != MaxAmount {
+		t.Errorf("unexpected collateral %+v", usdc)
+	}
+
+	shares, err := c.GetTokenBalance(ctx, "0xcond", SideDown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shares.Balance != AmountFromFloat(3) || shares.Available() != AmountFromFloat(1) {
+		t.Errorf("unexpected shares %+v (available %s)", shares, shares.Available())
+	}
+
+	// Neg-risk markets settle on the other exchange, which was never approved
+	c.infos["0xcond"].NegRisk = true
+	if usdc, _ = c.GetCollateralBalance(ctx, "0xcond"); usdc.Available() != 0 {
+		t.Errorf("expected no allowance on the neg-risk exchange, got %s", usdc.Allowance)
+	}
+}
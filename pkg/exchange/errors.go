@@ -0,0 +1,157 @@
+package exchange
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Kinds of exchange failures, matched with errors.Is. APIError and NetworkError
+// unwrap to them, as do the errors of orders refused before reaching the CLOB.
+var (
+	ErrRateLimited         = errors.New("rate limited")
+	ErrInsufficientBalance = errors.New("not enough balance or allowance")
+	ErrInvalidTick         = errors.New("price breaks the minimum tick size")
+	ErrOrderRejected       = errors.New("order rejected")
+	ErrNotFilled           = errors.New("order killed without a fill") // FOK/FAK found no liquidity
+	ErrMarketClosed        = errors.New("market closed")
+	ErrAuth                = errors.New("authentication failed")
+	ErrNetwork             = errors.New("network error")
+	ErrNotFound            = errors.New("not found") // E.g. an order the CLOB does not know
+)
+
+// APIError is returned when the CLOB rejects a request
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+// Unwrap returns the kind of failure, nil when it is not recognized
+func (e *APIError) Unwrap() error {
+	return classifyAPIError(e.StatusCode, e.Message)
+}
+
+// clobErrorKinds maps the CLOB error codes and messages to failure kinds
+var clobErrorKinds = []struct {
+	match string
+	kind  error
+}{
+	{"not enough balance", ErrInsufficientBalance},
+	{"allowance", ErrInsufficientBalance},
+	{"tick size", ErrInvalidTick},
+	{"fok_order_not_filled", ErrNotFilled},
+	{"no orders found to match", ErrNotFilled},
+	{"couldn't be fully filled", ErrNotFilled},
+	{"not yet ready", ErrMarketClosed},
+	{"market_not_ready", ErrMarketClosed},
+	{"not accepting orders", ErrMarketClosed},
+	{"market is closed", ErrMarketClosed},
+	{"closed market", ErrMarketClosed},
+	{"invalid_order", ErrOrderRejected},
+	{"invalid order", ErrOrderRejected},
+	{"order is invalid", ErrOrderRejected},
+	{"lower than the minimum", ErrOrderRejected},
+	{"invalid expiration", ErrOrderRejected},
+	{"api key", ErrAuth},
+	{"unauthorized", ErrAuth},
+}
+
+// classifyAPIError picks the kind of a CLOB failure from its status and message
+func classifyAPIError(status int, message string) error {
+	switch status {
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrAuth
+	case http.StatusNotFound:
+		return ErrNotFound
+	}
+	msg := strings.ToLower(message)
+	for _, k := range clobErrorKinds {
+		if strings.Contains(msg, k.match) {
+			return k.kind
+		}
+	}
+	if status < 300 {
+		return ErrOrderRejected // A successful request with success=false in the body
+	}
+	return nil
+}
+
+// NetworkError is a request that failed in transport, without a reply from the CLOB
+type NetworkError struct {
+	Err error
+}
+
+func (e *NetworkError) Error() string {
+	return "network error: " + e.Err.Error()
+}
+
+func (e *NetworkError) Unwrap() error { return e.Err }
+
+func (e *NetworkError) Is(target error) bool { return target == ErrNetwork }
+
+// OrderUnknownError is returned when an order may or may not have reached the CLOB,
+// e.g. after its post timed out and the lookups failed too. The order may be live:
+// follow it by ID instead of placing it again.
+type OrderUnknownError struct {
+	ID  string // Hash of the signed order, the CLOB order id
+	Err error
+}
+
+func (e *OrderUnknownError) Error() string {
+	return fmt.Sprintf("order %s in unknown state: %v", e.ID, e.Err)
+}
+
+func (e *OrderUnknownError) Unwrap() error { return e.Err }
+
+func (e *OrderUnknownError) Is(target error) bool { return target == ErrNetwork }
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("clob api error: status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("clob api error: status %d: %s", e.StatusCode, e.Message)
+}
+
+// newAPIError reads the `{"error": "..."}` body the CLOB sends with failures
+func newAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	var body struct {
+		Error    string `json:"error"`
+		ErrorMsg string `json:"errorMsg"`
+	}
+	if err := json.Unmarshal(raw, &body); err == nil {
+		apiErr.Message = body.Error
+		if apiErr.Message == "" {
+			apiErr.Message = body.ErrorMsg
+		}
+	} else {
+		apiErr.Message = string(raw)
+	}
+	return apiErr
+}
+
+// isNotFound reports whether the CLOB answered that the resource does not exist
+func isNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// isDuplicated reports whether the CLOB refused an order it already has, e.g. one
+// resubmitted after an ambiguous failure; the answer comes as an error or in the body
+func isDuplicated(err error, resp *orderResponse) bool {
+	msg := resp.ErrorMsg
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		msg = apiErr.Message
+	} else if err != nil {
+		return false
+	}
+	return strings.Contains(strings.ToLower(msg), "duplicated")
+}
@@ -0,0 +1,79 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+)
+
+func TestAPIErrorKinds(t *testing.T) {
+	cases := []struct {
+		status  int
+		message string
+		want    error
+	}{
+		{http.StatusTooManyRequests, "", ErrRateLimited},
+		{http.StatusUnauthorized, "Unauthorized/Invalid api key", ErrAuth},
+		{http.StatusBadRequest, "not enough balance / allowance", ErrInsufficientBalance},
+		{http.StatusBadRequest, "INVALID_ORDER_MIN_TICK_SIZE: order is invalid. Price (0.455), breaks minimum tick size rule: 0.01", ErrInvalidTick},
+		{http.StatusBadRequest, "INVALID_ORDER_DUPLICATED: order is invalid. Duplicated.", ErrOrderRejected},
+		{http.StatusBadRequest, "order couldn't be fully filled. FOK orders are fully filled or killed.", ErrNotFilled},
+		{http.StatusBadRequest, "the market is not yet ready to process new orders", ErrMarketClosed},
+		{http.StatusBadRequest, "MARKET_NOT_READY", ErrMarketClosed},
+		{http.StatusOK, "", ErrOrderRejected},
+		{http.StatusBadGateway, "", nil},
+	}
+	for _, tc := range cases {
+		err := error(&APIError{StatusCode: tc.status, Message: tc.message})
+		if tc.want == nil {
+			if errors.Unwrap(err) != nil {
+				t.Errorf("%d %q: expected no kind, got %v", tc.status, tc.message, errors.Unwrap(err))
+			}
+			continue
+		}
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%d %q: expected %v, got %v", tc.status, tc.message, tc.want, errors.Unwrap(err))
+		}
+	}
+}
+
+func TestNetworkError(t *testing.T) {
+	// Nothing listens on a port reserved and released right away
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	c := newTestClient(t, http.NotFound)
+	c.BaseURL = "http://" + addr
+	c.Retry = RetryPolicy{}
+
+	_, err = c.getOrderBook(context.Background(), "111")
+	if !errors.Is(err, ErrNetwork) {
+		t.Errorf("expected ErrNetwork, got %v", err)
+	}
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) || !notSent(err) {
+		t.Errorf("the network error should keep its cause, got %v", err)
+	}
+}
+
+func TestInvalidTickRefetchesMarketInfo(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": "INVALID_ORDER_MIN_TICK_SIZE: breaks minimum tick size rule: 0.001"}`))
+	})
+
+	_, err := c.PlaceOrder(ctx, "0xcond", SideUp, DirectionBuy, 10, 0.45, OrderOptions{})
+	if !errors.Is(err, ErrInvalidTick) {
+		t.Fatalf("expected ErrInvalidTick, got %v", err)
+	}
+	if _, cached := c.infos["0xcond"]; cached {
+		t.Error("stale market info should be dropped")
+	}
+}
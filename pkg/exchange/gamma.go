@@ -0,0 +1,250 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GammaURL is the public market metadata API
+const GammaURL = "https://gamma-api.polymarket.com"
+
+// ErrMarketNotFound is returned when no market matches a lookup
+var ErrMarketNotFound = errors.New("market not found")
+
+// GammaClient reads market metadata (slugs, schedules, token ids) from the Gamma API
+type GammaClient struct {
+	BaseURL string
+	Client  *http.Client
+}
+
+func NewGammaClient() *GammaClient {
+	return &GammaClient{
+		BaseURL: GammaURL,
+		Client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// GammaMarket is a market as listed by the Gamma API
+type GammaMarket struct {
+	ConditionID string
+	Slug        string
+	Question    string
+	Outcomes    []string
+	TokenIDs    []string // In the order of Outcomes
+
+	StartTime time.Time // Start of the event (round) the market is about
+	EndTime   time.Time
+
+	Active          bool
+	Closed          bool
+	AcceptingOrders bool
+	TickSize        float64
+	MinOrderSize    float64
+	NegRisk         bool
+	Liquidity       float64
+}
+
+// Market returns the outcome token mapping used by the CLOB client
+func (m *GammaMarket) Market() (Market, error) {
+	if len(m.TokenIDs) != 2 || len(m.Outcomes) != 2 {
+		return Market{}, fmt.Errorf("market %s is not a binary market", m.Slug)
+	}
+	return *newMarket(m.ConditionID, [2]string{m.TokenIDs[0], m.TokenIDs[1]}, m.Outcomes[0]), nil
+}
+
+// gammaMarket is the raw API shape; list fields come as JSON encoded strings
+type gammaMarket struct {
+	ConditionID           string  `json:"conditionId"`
+	Slug                  string  `json:"slug"`
+	Question              string  `json:"question"`
+	Outcomes              string  `json:"outcomes"`
+	ClobTokenIDs          string  `json:"clobTokenIds"`
+	StartDate             string  `json:"startDate"`
+	EventStartTime        string  `json:"eventStartTime"`
+	EndDate               string  `json:"endDate"`
+	Active                bool    `json:"active"`
+	Closed                bool    `json:"closed"`
+	AcceptingOrders       bool    `json:"acceptingOrders"`
+	OrderPriceMinTickSize float64 `json:"orderPriceMinTickSize"`
+	OrderMinSize          float64 `json:"orderMinSize"`
+	NegRisk               bool    `json:"negRisk"`
+	LiquidityNum          float64 `json:"liquidityNum"`
+}
+
+func (r *gammaMarket) toMarket() (*GammaMarket, error) {
+	m := &GammaMarket{
+		ConditionID:     r.ConditionID,
+		Slug:            r.Slug,
+		Question:        r.Question,
+		Active:          r.Active,
+		Closed:          r.Closed,
+		AcceptingOrders: r.AcceptingOrders,
+		TickSize:        r.OrderPriceMinTickSize,
+		MinOrderSize:    r.OrderMinSize,
+		NegRisk:         r.NegRisk,
+		Liquidity:       r.LiquidityNum,
+		EndTime:         parseGammaTime(r.EndDate),
+	}
+	if r.Outcomes != "" {
+		if err := json.Unmarshal([]byte(r.Outcomes), &m.Outcomes); err != nil {
+			return nil, fmt.Errorf("invalid outcomes of %s: %v", r.Slug, err)
+		}
+	}
+	if r.ClobTokenIDs != "" {
+		if err := json.Unmarshal([]byte(r.ClobTokenIDs), &m.TokenIDs); err != nil {
+			return nil, fmt.Errorf("invalid token ids of %s: %v", r.Slug, err)
+		}
+	}
+
+	// startDate is when the market was listed, which for recurring markets is well
+	// before the round it is about
+	m.StartTime = parseGammaTime(r.EventStartTime)
+	if m.StartTime.IsZero() {
+		m.StartTime = parseGammaTime(r.StartDate)
+	}
+	return m, nil
+}
+
+func parseGammaTime(v string) time.Time {
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// MarketBySlug looks a market up by its slug
+func (g *GammaClient) MarketBySlug(ctx context.Context, slug string) (*GammaMarket, error) {
+	markets, err := g.markets(ctx, url.Values{"slug": {slug}})
+	if err != nil {
+		return nil, err
+	}
+	if len(markets) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrMarketNotFound, slug)
+	}
+	return markets[0], nil
+}
+
+// MarketQuery selects active markets; empty fields match everything
+type MarketQuery struct {
+	Keyword string // Case-insensitive match on the question or slug
+	Tag     string // Tag slug, e.g. "crypto"
+	Series  string // Case-insensitive slug prefix of a recurring market, e.g. "btc-updown-15m"
+	Limit   int    // Maximum number of markets, 50 when zero
+}
+
+func (q *MarketQuery) matches(m *GammaMarket) bool {
+	slug := strings.ToLower(m.Slug)
+	if q.Series != "" && !strings.HasPrefix(slug, strings.ToLower(q.Series)) {
+		return false
+	}
+	if q.Keyword != "" {
+		kw := strings.ToLower(q.Keyword)
+		if !strings.Contains(strings.ToLower(m.Question), kw) && !strings.Contains(slug, kw) {
+			return false
+		}
+	}
+	return true
+}
+
+// Paging of /events when searching; the page cap bounds searches that match little
+const (
+	gammaEventsPage = 100
+	gammaMaxPages   = 20
+)
+
+// SearchMarkets lists active markets accepting orders, most liquid first. Events are
+// paged through until Limit markets match; keyword and series are matched locally
+// since the API has no text filter on /events.
+func (g *GammaClient) SearchMarkets(ctx context.Context, q MarketQuery) ([]*GammaMarket, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+
+	query := url.Values{
+		"active": {"true"},
+		"closed": {"false"},
+		"limit":  {strconv.Itoa(gammaEventsPage)},
+	}
+	if q.Tag != "" {
+		query.Set("tag_slug", q.Tag)
+	}
+
+	var found []*GammaMarket
+	for page := 0; page < gammaMaxPages && len(found) < limit; page++ {
+		query.Set("offset", strconv.Itoa(page*gammaEventsPage))
+		var events []struct {
+			Markets []gammaMarket `json:"markets"`
+		}
+		if err := g.get(ctx, "/events", query, &events); err != nil {
+			return nil, err
+		}
+
+		for _, ev := range events {
+			for i := range ev.Markets {
+				m, err := ev.Markets[i].toMarket()
+				if err != nil {
+					log.Printf("gamma: skipping market: %v", err)
+					continue
+				}
+				if m.AcceptingOrders && !m.Closed && q.matches(m) {
+					found = append(found, m)
+				}
+			}
+		}
+		if len(events) < gammaEventsPage {
+			break
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool { return found[i].Liquidity > found[j].Liquidity })
+	if len(found) > limit {
+		found = found[:limit]
+	}
+	return found, nil
+}
+
+// markets queries GET /markets with the given filters
+func (g *GammaClient) markets(ctx context.Context, query url.Values) ([]*GammaMarket, error) {
+	var raw []gammaMarket
+	if err := g.get(ctx, "/markets", query, &raw); err != nil {
+		return nil, err
+	}
+	markets := make([]*GammaMarket, 0, len(raw))
+	for i := range raw {
+		m, err := raw[i].toMarket()
+		if err != nil {
+			return nil, err
+		}
+		markets = append(markets, m)
+	}
+	return markets, nil
+}
+
+// get decodes the JSON response of a Gamma endpoint
+func (g *GammaClient) get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", g.BaseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := g.Client.Do(req)
+	if err != nil {
+		return transportError(req, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return newAPIError(resp)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
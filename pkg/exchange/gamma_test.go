@@ -0,0 +1,132 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGammaMarketBySlug(t *testing.T) {
+	ctx := context.Background()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/markets" || r.URL.Query().Get("slug") != "btc-updown-15m-1760000400" {
+			w.Write([]byte(`[]`))
+			return
+		}
+		w.Write([]byte(`[{
+			"conditionId": "0xround",
+			"slug": "btc-updown-15m-1760000400",
+			"question": "Bitcoin Up or Down?",
+			"outcomes": "[\"Down\", \"Up\"]",
+			"clobTokenIds": "[\"2002\", \"1001\"]",
+			"startDate": "2025-10-08T09:00:00Z",
+			"eventStartTime": "2025-10-09T09:00:00Z",
+			"endDate": "2025-10-09T09:15:00Z",
+			"active": true,
+			"acceptingOrders": true,
+			"orderPriceMinTickSize": 0.01,
+			"orderMinSize": 5,
+			"liquidityNum": 12345.5
+		}]`))
+	}))
+	defer srv.Close()
+
+	g := NewGammaClient()
+	g.BaseURL = srv.URL
+
+	gm, err := g.MarketBySlug(ctx, "btc-updown-15m-1760000400")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !gm.StartTime.Equal(time.Date(2025, 10, 9, 9, 0, 0, 0, time.UTC)) || gm.EndTime.Sub(gm.StartTime) != 15*time.Minute {
+		t.Errorf("round times = %v - %v", gm.StartTime, gm.EndTime)
+	}
+	if gm.TickSize != 0.01 || gm.MinOrderSize != 5 || gm.Liquidity != 12345.5 || !gm.AcceptingOrders {
+		t.Errorf("unexpected market %+v", gm)
+	}
+
+	m, err := gm.Market()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.ID != "0xround" || m.TokenUp != "1001" || m.TokenDown != "2002" {
+		t.Errorf("unexpected token mapping %+v", m)
+	}
+
+	if _, err := g.MarketBySlug(ctx, "btc-updown-15m-0"); !errors.Is(err, ErrMarketNotFound) {
+		t.Errorf("expected ErrMarketNotFound, got %v", err)
+	}
+}
+
+func TestGammaSearchMarkets(t *testing.T) {
+	ctx := context.Background()
+	pages := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/events" || q.Get("active") != "true" || q.Get("closed") != "false" || q.Get("tag_slug") != "crypto" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		pages++
+		if q.Get("offset") != "0" {
+			w.Write([]byte(`[]`))
+			return
+		}
+		w.Write([]byte(`[
+			{"slug": "btc-updown-15m-1760000400", "markets": [
+				{"conditionId": "0xa", "slug": "btc-updown-15m-1760000400", "question": "Bitcoin Up or Down?",
+				 "outcomes": "[\"Up\", \"Down\"]", "clobTokenIds": "[\"1\", \"2\"]", "acceptingOrders": true, "liquidityNum": 100}]},
+			{"slug": "btc-updown-15m-1760001300", "markets": [
+				{"conditionId": "0xb", "slug": "btc-updown-15m-1760001300", "question": "Bitcoin Up or Down?",
+				 "outcomes": "[\"Up\", \"Down\"]", "clobTokenIds": "[\"3\", \"4\"]", "acceptingOrders": true, "liquidityNum": 900}]},
+			{"slug": "eth-above", "markets": [
+				{"conditionId": "0xc", "slug": "eth-above-4000", "question": "Ethereum above 4000?",
+				 "outcomes": "[\"Yes\", \"No\"]", "clobTokenIds": "[\"5\", \"6\"]", "acceptingOrders": true, "liquidityNum": 500},
+				{"conditionId": "0xd", "slug": "eth-above-5000", "question": "Ethereum above 5000?",
+				 "outcomes": "[\"Yes\", \"No\"]", "clobTokenIds": "[\"7\", \"8\"]", "acceptingOrders": false},
+				{"conditionId": "0xe", "slug": "eth-above-6000", "question": "Ethereum above 6000?",
+				 "outcomes": "Yes/No", "clobTokenIds": "[\"9\", \"10\"]", "acceptingOrders": true}]}
+		]`))
+	}))
+	defer srv.Close()
+
+	g := NewGammaClient()
+	g.BaseURL = srv.URL
+
+	markets, err := g.SearchMarkets(ctx, MarketQuery{Tag: "crypto", Series: "btc-updown-15m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(markets) != 2 || markets[0].ConditionID != "0xb" || markets[1].ConditionID != "0xa" {
+		t.Errorf("expected both rounds, most liquid first, got %+v", markets)
+	}
+	if pages != 1 {
+		t.Errorf("expected a single page, got %d requests", pages)
+	}
+
+	// Markets not accepting orders are left out, as are malformed ones
+	markets, err = g.SearchMarkets(ctx, MarketQuery{Tag: "crypto", Keyword: "ethereum"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(markets) != 1 || markets[0].ConditionID != "0xc" {
+		t.Errorf("expected the open ethereum market, got %+v", markets)
+	}
+
+	// Series and keywords ignore case on both sides
+	markets, _ = g.SearchMarkets(ctx, MarketQuery{Tag: "crypto", Series: "BTC-UpDown-15m"})
+	if len(markets) != 2 {
+		t.Errorf("expected both rounds for an upper-case series, got %+v", markets)
+	}
+	markets, _ = g.SearchMarkets(ctx, MarketQuery{Tag: "crypto", Keyword: "ETH-ABOVE"})
+	if len(markets) != 1 || markets[0].ConditionID != "0xc" {
+		t.Errorf("expected the slug to match an upper-case keyword, got %+v", markets)
+	}
+
+	markets, _ = g.SearchMarkets(ctx, MarketQuery{Tag: "crypto", Limit: 1})
+	if len(markets) != 1 || markets[0].ConditionID != "0xb" {
+		t.Errorf("expected the most liquid market, got %+v", markets)
+	}
+}
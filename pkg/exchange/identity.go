@@ -0,0 +1,125 @@
+package exchange
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+)
+
+// maxSalt keeps salts within the integers JSON clients parse exactly (2^53)
+const maxSalt = 1 << 53
+
+// OrderIdentities issues the salt and nonce each order is signed with, and
+// remembers which client order a salt or order hash belongs to until the order
+// is final.
+//
+// The CTF Exchange only fills orders signed with the maker's current nonce on
+// that exchange; incrementing it on chain invalidates every order signed before.
+type OrderIdentities struct {
+	mu       sync.Mutex
+	nonces   map[string]*big.Int // Per exchange contract, by lower-case address
+	bySalt   map[int64]string    // Client order id by salt, of the orders not final yet
+	byHash   map[string]int64    // Salt by order hash
+	clientID uint64
+}
+
+// NewOrderIdentities creates a manager signing with nonce 0 until SetNonce is called
+func NewOrderIdentities() *OrderIdentities {
+	return &OrderIdentities{
+		nonces: make(map[string]*big.Int),
+		bySalt: make(map[int64]string),
+		byHash: make(map[string]int64),
+	}
+}
+
+// Nonce returns the nonce orders for the exchange contract are signed with
+func (ids *OrderIdentities) Nonce(exchangeAddr string) *big.Int {
+	ids.mu.Lock()
+	defer ids.mu.Unlock()
+	if n, ok := ids.nonces[strings.ToLower(exchangeAddr)]; ok {
+		return new(big.Int).Set(n)
+	}
+	return big.NewInt(0)
+}
+
+// SetNonce records the maker's nonce on the exchange contract, e.g. as read from
+// chain at startup or after incrementing it
+func (ids *OrderIdentities) SetNonce(exchangeAddr string, nonce *big.Int) {
+	ids.mu.Lock()
+	defer ids.mu.Unlock()
+	ids.nonces[strings.ToLower(exchangeAddr)] = new(big.Int).Set(nonce)
+}
+
+// next issues a salt no earlier order used, for the client order id; an empty
+// clientID gets a generated one
+func (ids *OrderIdentities) next(clientID string) (int64, string, error) {
+	ids.mu.Lock()
+	defer ids.mu.Unlock()
+	if clientID == "" {
+		ids.clientID++
+		clientID = fmt.Sprintf("order-%d", ids.clientID)
+	}
+
+	var b [8]byte
+	for {
+		if _, err := rand.Read(b[:]); err != nil {
+			return 0, "", fmt.Errorf("generating salt: %w", err)
+		}
+		salt := int64(binary.BigEndian.Uint64(b[:]) % maxSalt)
+		if _, used := ids.bySalt[salt]; !used {
+			ids.bySalt[salt] = clientID
+			return salt, clientID, nil
+		}
+	}
+}
+
+// bind records the hash (CLOB order id) of the order signed with salt
+func (ids *OrderIdentities) bind(salt int64, orderID string) {
+	ids.mu.Lock()
+	defer ids.mu.Unlock()
+	if _, ok := ids.bySalt[salt]; ok {
+		ids.byHash[strings.ToLower(orderID)] = salt
+	}
+}
+
+// release drops the salt of an order that was never sent
+func (ids *OrderIdentities) release(salt int64) {
+	ids.mu.Lock()
+	defer ids.mu.Unlock()
+	delete(ids.bySalt, salt)
+}
+
+// forget drops an order that can no longer fill, or never reached the book, so
+// a long-running client does not accumulate the identities of past orders
+func (ids *OrderIdentities) forget(orderID string) {
+	ids.mu.Lock()
+	defer ids.mu.Unlock()
+	orderID = strings.ToLower(orderID)
+	if salt, ok := ids.byHash[orderID]; ok {
+		delete(ids.bySalt, salt)
+		delete(ids.byHash, orderID)
+	}
+}
+
+// ClientID returns the client order id of the order with the given hash
+func (ids *OrderIdentities) ClientID(orderID string) (string, bool) {
+	ids.mu.Lock()
+	defer ids.mu.Unlock()
+	salt, ok := ids.byHash[strings.ToLower(orderID)]
+	if !ok {
+		return "", false
+	}
+	clientID, ok := ids.bySalt[salt]
+	return clientID, ok
+}
+
+// ClientIDBySalt returns the client order id of the order signed with salt
+func (ids *OrderIdentities) ClientIDBySalt(salt int64) (string, bool) {
+	ids.mu.Lock()
+	defer ids.mu.Unlock()
+	clientID, ok := ids.bySalt[salt]
+	return clientID, ok
+}
@@ -0,0 +1,164 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"poly/pkg/signer"
+)
+
+func TestOrderIdentities(t *testing.T) {
+	ids := NewOrderIdentities()
+
+	// Salts never repeat and stay JSON-safe; client ids are generated when missing
+	salts := make(map[int64]bool)
+	for i := 0; i < 1000; i++ {
+		salt, clientID, err := ids.next("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if salts[salt] || salt < 0 || salt >= maxSalt {
+			t.Fatalf("salt %d reused or out of range", salt)
+		}
+		salts[salt] = true
+		if clientID == "" {
+			t.Fatal("expected a generated client id")
+		}
+	}
+
+	salt, clientID, _ := ids.next("leg-1")
+	if clientID != "leg-1" {
+		t.Errorf("client id = %q, want leg-1", clientID)
+	}
+	ids.bind(salt, "0xABCD")
+	if got, ok := ids.ClientID("0xabcd"); !ok || got != "leg-1" {
+		t.Errorf("ClientID(hash) = %q, %v", got, ok)
+	}
+	if got, ok := ids.ClientIDBySalt(salt); !ok || got != "leg-1" {
+		t.Errorf("ClientIDBySalt = %q, %v", got, ok)
+	}
+	if _, ok := ids.ClientID("0x1234"); ok {
+		t.Error("unknown order should have no client id")
+	}
+
+	// Final orders are forgotten along with their salt, as are salts never sent
+	ids.forget("0xAbCd")
+	if _, ok := ids.ClientID("0xabcd"); ok {
+		t.Error("forgotten order should have no client id")
+	}
+	if _, ok := ids.ClientIDBySalt(salt); ok {
+		t.Error("forgotten salt should have no client id")
+	}
+	unsent, _, _ := ids.next("")
+	ids.release(unsent)
+	if len(ids.bySalt) != 1000 || len(ids.byHash) != 0 {
+		t.Errorf("expected only the 1000 unbound salts left, got %d salts and %d hashes", len(ids.bySalt), len(ids.byHash))
+	}
+
+	// Nonces are kept per exchange contract
+	ids.SetNonce(CTFExchangeAddress, big.NewInt(2))
+	if n := ids.Nonce(common.HexToAddress(CTFExchangeAddress).Hex()); n.Int64() != 2 {
+		t.Errorf("CTF Exchange nonce = %v, want 2", n)
+	}
+	if n := ids.Nonce(NegRiskCTFExchangeAddress); n.Sign() != 0 {
+		t.Errorf("Neg Risk CTF Exchange nonce = %v, want 0", n)
+	}
+}
+
+func TestPlaceOrderIdentity(t *testing.T) {
+	ctx := context.Background()
+	var c *PolymarketClient
+	status := "LIVE"
+	c = newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/order":
+			var payload struct {
+				Order signedOrder `json:"order"`
+			}
+			if !decodeBody(t, w, r, &payload) {
+				return
+			}
+			if payload.Order.Nonce != "7" {
+				t.Errorf("order signed with nonce %s, want 7", payload.Order.Nonce)
+			}
+			// Reply with the order hash, as the CLOB does
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": true, "orderID": orderHash(t, c, &payload.Order), "status": "live",
+			})
+		case r.Method == "GET":
+			w.Write([]byte(`{"id":"` + r.URL.Path[len("/data/order/"):] + `","status":"` + status + `","market":"0xcond","asset_id":"111","original_size":"10","size_matched":"0","price":"0.4"}`))
+		}
+	})
+	c.Orders.SetNonce(CTFExchangeAddress, big.NewInt(7))
+
+	first, err := c.PlaceOrder(ctx, "0xcond", SideUp, DirectionBuy, 10, 0.40, OrderOptions{ClientID: "cycle-1-leg-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := c.PlaceOrder(ctx, "0xcond", SideUp, DirectionBuy, 10, 0.40, OrderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.ID == second.ID {
+		t.Errorf("identical orders share the hash %s", first.ID)
+	}
+	if first.ClientID != "cycle-1-leg-1" || second.ClientID == "" {
+		t.Errorf("client ids %q and %q", first.ClientID, second.ClientID)
+	}
+
+	// Orders fetched back resolve to our client id
+	order, err := c.GetOrder(ctx, first.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.ClientID != "cycle-1-leg-1" {
+		t.Errorf("fetched order client id = %q", order.ClientID)
+	}
+
+	// Once seen final, the order is forgotten
+	status = "MATCHED"
+	if order, err := c.GetOrder(ctx, first.ID); err != nil || order.ClientID != "cycle-1-leg-1" {
+		t.Fatalf("fetched final order %+v (err %v)", order, err)
+	}
+	if _, ok := c.Orders.ClientID(first.ID); ok {
+		t.Error("expected the final order to be forgotten")
+	}
+}
+
+// orderHash computes the CLOB id of an order as sent to POST /order. It runs in test
+// handlers, so it reports failures with t.Errorf.
+func orderHash(t *testing.T, c *PolymarketClient, o *signedOrder) string {
+	t.Helper()
+	num := func(s string) *big.Int {
+		n, _ := new(big.Int).SetString(s, 10)
+		return n
+	}
+	side := orderSideBuy
+	if o.Side == "SELL" {
+		side = orderSideSell
+	}
+	hash, err := signer.TypedDataHash(c.orderTypedData(&orderData{
+		Salt:          big.NewInt(o.Salt),
+		Maker:         common.HexToAddress(o.Maker),
+		Signer:        common.HexToAddress(o.Signer),
+		Taker:         common.HexToAddress(o.Taker),
+		TokenID:       num(o.TokenID),
+		MakerAmount:   num(o.MakerAmount),
+		TakerAmount:   num(o.TakerAmount),
+		Expiration:    num(o.Expiration),
+		Nonce:         num(o.Nonce),
+		FeeRateBps:    num(o.FeeRateBps),
+		Side:          side,
+		SignatureType: SignatureType(o.SignatureType),
+	}, CTFExchangeAddress))
+	if err != nil {
+		t.Errorf("hashing order: %v", err)
+	}
+	return hexutil.Encode(hash)
+}
@@ -1,6 +1,10 @@
 package exchange
 
-import "time"
+import (
+	"context"
+	"strings"
+	"time"
+)
 
 // Side represents the outcome side (UP/DOWN or YES/NO)
 type Side string
@@ -10,14 +14,155 @@ const (
 	SideDown Side = "DOWN"
 )
 
+// Direction is whether an order buys or sells outcome shares
+type Direction string
+
+const (
+	DirectionBuy  Direction = "BUY"
+	DirectionSell Direction = "SELL"
+)
+
+// Market maps a market (condition) to the token ids of its two outcomes
+type Market struct {
+	ID        string // Condition ID, used as the MarketID everywhere else
+	TokenUp   string // Token ID of the UP/YES outcome
+	TokenDown string // Token ID of the DOWN/NO outcome
+}
+
+// TokenID returns the outcome token id for the given side
+func (m *Market) TokenID(side Side) string {
+	if side == SideDown {
+		return m.TokenDown
+	}
+	return m.TokenUp
+}
+
+// OrderStatus is the lifecycle state of an order
+type OrderStatus string
+
+const (
+	OrderLive            OrderStatus = "LIVE"             // Resting on the book, nothing filled
+	OrderPartiallyFilled OrderStatus = "PARTIALLY_FILLED" // Resting on the book, partly filled
+	OrderMatched         OrderStatus = "MATCHED"          // Fully filled
+	OrderCancelled       OrderStatus = "CANCELLED"
+	OrderExpired         OrderStatus = "EXPIRED"
+)
+
+// IsFinal reports whether the order can no longer fill
+func (s OrderStatus) IsFinal() bool {
+	return s == OrderMatched || s == OrderCancelled || s == OrderExpired
+}
+
+// parseOrderStatus normalizes the CLOB status strings
+func parseOrderStatus(status string, sizeMatched float64) OrderStatus {
+	switch strings.ToUpper(status) {
+	case "MATCHED":
+		return OrderMatched
+	case "CANCELED", "CANCELLED", "CANCELED_MARKET_RESOLVED", "UNMATCHED":
+		// "unmatched" is a marketable order that failed to match after a delay
+		return OrderCancelled
+	case "EXPIRED":
+		return OrderExpired
+	}
+	// "live" and "delayed" orders are still working
+	if sizeMatched > 0 {
+		return OrderPartiallyFilled
+	}
+	return OrderLive
+}
+
+// OrderType controls how long an order works on the book
+type OrderType string
+
+const (
+	OrderTypeGTC OrderType = "GTC" // Good til cancelled
+	OrderTypeGTD OrderType = "GTD" // Good til date, see OrderOptions.Expiration
+	OrderTypeFOK OrderType = "FOK" // Fill entirely right away or cancel
+	OrderTypeFAK OrderType = "FAK" // Fill what is available right away, cancel the rest
+)
+
+// OrderOptions tunes how an order is placed; the zero value is a GTC order
+type OrderOptions struct {
+	Type       OrderType
+	Expiration time.Time // Required for GTD orders
+	ClientID   string    // Our id for the order, generated when empty
+}
+
+// orderType returns the requested type, defaulting to GTC
+func (o OrderOptions) orderType() OrderType {
+	if o.Type == "" {
+		return OrderTypeGTC
+	}
+	return o.Type
+}
+
 // Order represents a trade order
 type Order struct {
 	ID        string
+	ClientID  string // Set on orders placed by this process, see OrderOptions.ClientID
 	MarketID  string
-	Side      Side
-	Price     float64
+	Side      Side      // Outcome traded
+	Direction Direction // BUY or SELL
+	Price     float64   // Limit price
 	Size      float64
 	Timestamp time.Time
+
+	Type       OrderType
+	Expiration time.Time // Zero unless GTD
+
+	Status      OrderStatus
+	SizeMatched float64 // Shares filled so far
+	AvgPrice    float64 // Average fill price, 0 when unknown
+
+	// Exact signed amounts: for a BUY, maker = USDC paid and taker = shares;
+	// for a SELL, maker = shares and taker = USDC received
+	MakerAmount Amount
+	TakerAmount Amount
+
+	// MatchedCost is the exact USDC of the fills (paid for a BUY, received for a
+	// SELL), 0 when the exchange did not report it
+	MatchedCost Amount
+}
+
+func (o *Order) copy() *Order {
+	cp := *o
+	return &cp
+}
+
+// FillPrice returns the average fill price, falling back to the limit price
+func (o *Order) FillPrice() float64 {
+	if o.AvgPrice > 0 {
+		return o.AvgPrice
+	}
+	return o.Price
+}
+
+// Cost returns the exact USDC value (paid for a BUY, received for a SELL) of the filled part of the order,
+// or of the whole order when nothing is filled yet. Without a reported fill value it is the
+// signed USDC amount, prorated to the shares filled.
+func (o *Order) Cost() Amount {
+	if o.SizeMatched > 0 && o.MatchedCost > 0 {
+		return o.MatchedCost
+	}
+
+	usdc, shares := o.MakerAmount, o.TakerAmount
+	if o.Direction == DirectionSell {
+		usdc, shares = shares, usdc
+	}
+	if usdc <= 0 || shares <= 0 {
+		// Not placed by this process: only the limit price is known
+		size := o.Size
+		if o.SizeMatched > 0 {
+			size = o.SizeMatched
+		}
+		return AmountFromFloat(o.FillPrice()).Mul(AmountFromFloat(size), RoundDown)
+	}
+
+	matched := AmountFromFloat(o.SizeMatched)
+	if matched <= 0 || matched >= shares {
+		return usdc
+	}
+	return usdc.Mul(matched, RoundDown).Quo(shares, RoundDown)
 }
 
 // Ticker represents the current best prices
@@ -28,13 +173,120 @@ type Ticker struct {
 	Timestamp time.Time
 }
 
+// Price returns the exact best ask for the given side
+func (t *Ticker) Price(side Side) Amount {
+	if side == SideDown {
+		return AmountFromFloat(t.PriceDown)
+	}
+	return AmountFromFloat(t.PriceUp)
+}
+
+// TradeStatus is the settlement state of a trade
+type TradeStatus string
+
+const (
+	TradeMatched   TradeStatus = "MATCHED"   // Matched by the operator, not yet on chain
+	TradeMined     TradeStatus = "MINED"     // Included in a block
+	TradeConfirmed TradeStatus = "CONFIRMED" // Final
+	TradeRetrying  TradeStatus = "RETRYING"  // Transaction failed, being resubmitted
+	TradeFailed    TradeStatus = "FAILED"    // Failed for good, the fill did not happen
+)
+
+// UserEventType distinguishes the events of the user channel
+type UserEventType string
+
+const (
+	UserEventTrade UserEventType = "TRADE" // A fill of one of our orders
+	UserEventOrder UserEventType = "ORDER" // An order was placed, updated or cancelled
+)
+
+// UserEvent is a real-time fill or order update of the account
+type UserEvent struct {
+	Type      UserEventType
+	MarketID  string
+	OrderID   string // Our order the event is about
+	Side      Side   // Outcome, empty when the token is unknown
+	Direction Direction
+	Price     float64
+	Timestamp time.Time
+
+	// Trade events
+	TradeID     string
+	TradeStatus TradeStatus
+	Size        float64 // Shares of our order filled by the trade
+
+	// Order events
+	OrderStatus OrderStatus
+	SizeMatched float64 // Shares filled so far
+}
+
+// Trade is a fill of one of the account's orders
+type Trade struct {
+	ID        string // Shared by the orders matched together
+	MarketID  string
+	OrderID   string
+	Side      Side
+	Direction Direction
+	Price     float64
+	Size      float64
+	Status    TradeStatus
+	Timestamp time.Time
+}
+
+// Position is the funder's holding of one outcome token
+type Position struct {
+	MarketID string
+	Side     Side
+	TokenID  string
+	Size     float64 // Shares held
+	AvgPrice float64 // Average entry price
+}
+
+// TickerSource pushes tickers as prices change, e.g. a MarketStream
+type TickerSource interface {
+	Tickers() <-chan *Ticker
+}
+
+// UserEventSource streams the fills and order updates of the account
+type UserEventSource interface {
+	UserEvents() <-chan *UserEvent
+}
+
 // Exchange defines the interface for interacting with the market
 type Exchange interface {
 	// GetTicker returns the latest prices
-	GetTicker(marketID string) (*Ticker, error)
+	GetTicker(ctx context.Context, marketID string) (*Ticker, error)
+
+	// PlaceOrder places a limit order (or a market order via limit with FOK/FAK)
+	// buying or selling size shares of the side's outcome
+	PlaceOrder(ctx context.Context, marketID string, side Side, dir Direction, size float64, price float64, opts OrderOptions) (*Order, error)
+
+	// GetOrder returns the current state of an order
+	GetOrder(ctx context.Context, orderID string) (*Order, error)
+
+	// CancelOrder pulls a resting order from the book
+	CancelOrder(ctx context.Context, orderID string) error
+
+	// CancelAll pulls every open order of the account
+	CancelAll(ctx context.Context) error
+
+	// OpenOrders lists the resting orders in a market
+	OpenOrders(ctx context.Context, marketID string) ([]*Order, error)
+
+	// GetMarketInfo returns the tick size, minimum order size and neg-risk flag
+	GetMarketInfo(ctx context.Context, marketID string) (*MarketInfo, error)
+
+	// GetCollateralBalance returns the funder's USDC and the allowance of the market's exchange
+	GetCollateralBalance(ctx context.Context, marketID string) (*Balance, error)
+
+	// GetTokenBalance returns the funder's shares of a side's outcome token
+	GetTokenBalance(ctx context.Context, marketID string, side Side) (*Balance, error)
+
+	// GetTrades lists the account's fills in a market
+	GetTrades(ctx context.Context, marketID string) ([]*Trade, error)
 
-	// PlaceOrder places a limit order (or market buy via limit)
-	PlaceOrder(marketID string, side Side, size float64, price float64) (*Order, error)
+	// GetPositions lists the funder's outcome token holdings; an empty marketID lists every market
+	GetPositions(ctx context.Context, marketID string) ([]*Position, error)
 
 	// CurrentTime returns the exchange time (useful for backtesting)
 	CurrentTime() time.Time
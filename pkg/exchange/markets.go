@@ -0,0 +1,141 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Verifying contracts of the EIP-712 order domain on Polygon
+const (
+	CTFExchangeAddress        = "0x4bFb41d5B3570DeFd03C39a9A4D8dE6Bd8B8982E"
+	NegRiskCTFExchangeAddress = "0xC5d563A36AE78145C45a50134d48A1215220f80a"
+)
+
+// marketInfoTTL bounds how long cached metadata is trusted; the tick size of a
+// market shrinks once its price approaches 0 or 1.
+const marketInfoTTL = 5 * time.Minute
+
+// MarketInfo holds the trading properties of a market
+type MarketInfo struct {
+	MarketID        string
+	TickSize        Amount // Minimum price increment (e.g. 0.01)
+	MinOrderSize    Amount // Minimum order size in shares
+	NegRisk         bool   // Orders settle on the neg-risk exchange
+	AcceptingOrders bool
+
+	fetchedAt time.Time
+}
+
+// ExchangeAddress returns the verifying contract orders for this market are signed against
+func (i *MarketInfo) ExchangeAddress() string {
+	if i.NegRisk {
+		return NegRiskCTFExchangeAddress
+	}
+	return CTFExchangeAddress
+}
+
+// forgetMarketInfo drops the cached metadata of a market
+func (c *PolymarketClient) forgetMarketInfo(marketID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.infos, marketID)
+}
+
+// RegisterMarket makes a market's outcome tokens known to the client
+func (c *PolymarketClient) RegisterMarket(m Market) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.markets[m.ID] = &m
+}
+
+// Market resolves the outcome tokens, looking the market up when it was not registered
+func (c *PolymarketClient) Market(ctx context.Context, marketID string) (*Market, error) {
+	c.mu.RLock()
+	m, ok := c.markets[marketID]
+	c.mu.RUnlock()
+	if ok {
+		return m, nil
+	}
+
+	if _, err := c.GetMarketInfo(ctx, marketID); err != nil {
+		return nil, fmt.Errorf("unknown market %s: %v", marketID, err)
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if m, ok := c.markets[marketID]; ok {
+		return m, nil
+	}
+	return nil, fmt.Errorf("unknown market: %s", marketID)
+}
+
+type marketResponse struct {
+	ConditionID      string      `json:"condition_id"`
+	MinimumTickSize  json.Number `json:"minimum_tick_size"`
+	MinimumOrderSize json.Number `json:"minimum_order_size"`
+	NegRisk          bool        `json:"neg_risk"`
+	AcceptingOrders  bool        `json:"accepting_orders"`
+	Tokens           []struct {
+		TokenID string `json:"token_id"`
+		Outcome string `json:"outcome"`
+	} `json:"tokens"`
+}
+
+// GetMarketInfo returns the tick size, minimum size and neg-risk flag of a market.
+// Results are cached for marketInfoTTL; the outcome tokens are registered as a side effect.
+func (c *PolymarketClient) GetMarketInfo(ctx context.Context, marketID string) (*MarketInfo, error) {
+	c.mu.RLock()
+	info, ok := c.infos[marketID]
+	c.mu.RUnlock()
+	if ok && time.Since(info.fetchedAt) < marketInfoTTL {
+		return info, nil
+	}
+
+	// Endpoint: GET /markets/{condition_id}
+	req, err := http.NewRequestWithContext(ctx, "GET", c.BaseURL+"/markets/"+url.PathEscape(marketID), nil)
+	if err != nil {
+		return nil, err
+	}
+	var resp marketResponse
+	if err := c.do(req, &resp); err != nil {
+		return nil, err
+	}
+
+	info = &MarketInfo{
+		MarketID:        marketID,
+		NegRisk:         resp.NegRisk,
+		AcceptingOrders: resp.AcceptingOrders,
+		fetchedAt:       time.Now(),
+	}
+	if info.TickSize, err = ParseAmount(resp.MinimumTickSize.String()); err != nil {
+		return nil, fmt.Errorf("invalid tick size: %v", err)
+	}
+	if info.MinOrderSize, err = ParseAmount(resp.MinimumOrderSize.String()); err != nil {
+		return nil, fmt.Errorf("invalid minimum order size: %v", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.infos[marketID] = info
+	if _, ok := c.markets[marketID]; !ok && len(resp.Tokens) == 2 {
+		c.markets[marketID] = newMarket(marketID,
+			[2]string{resp.Tokens[0].TokenID, resp.Tokens[1].TokenID},
+			resp.Tokens[0].Outcome)
+	}
+	return info, nil
+}
+
+// newMarket maps the two outcome tokens to UP/DOWN given the outcome of the first one.
+// Outcomes are usually ordered Yes/No or Up/Down, but don't rely on it.
+func newMarket(marketID string, tokens [2]string, firstOutcome string) *Market {
+	m := &Market{ID: marketID, TokenUp: tokens[0], TokenDown: tokens[1]}
+	if o := strings.ToLower(firstOutcome); o == "no" || o == "down" {
+		m.TokenUp, m.TokenDown = m.TokenDown, m.TokenUp
+	}
+	return m
+}
@@ -0,0 +1,61 @@
+package exchange
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestGetMarketInfo(t *testing.T) {
+	ctx := context.Background()
+	calls := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/markets/0xneg" {
+			http.NotFound(w, r)
+			return
+		}
+		calls++
+		w.Write([]byte(`{
+			"condition_id": "0xneg",
+			"minimum_tick_size": 0.001,
+			"minimum_order_size": 15,
+			"neg_risk": true,
+			"accepting_orders": true,
+			"tokens": [{"token_id": "900", "outcome": "No"}, {"token_id": "800", "outcome": "Yes"}]
+		}`))
+	})
+
+	info, err := c.GetMarketInfo(ctx, "0xneg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.TickSize.String() != "0.001" || info.MinOrderSize.String() != "15" || !info.NegRisk {
+		t.Errorf("unexpected info %+v", info)
+	}
+	if info.ExchangeAddress() != NegRiskCTFExchangeAddress {
+		t.Errorf("neg-risk market should use the neg-risk exchange, got %s", info.ExchangeAddress())
+	}
+
+	// Cached, and the outcome tokens were registered
+	if _, err := c.GetMarketInfo(ctx, "0xneg"); err != nil || calls != 1 {
+		t.Errorf("expected cached info, got %d calls (err %v)", calls, err)
+	}
+	m, err := c.Market(ctx, "0xneg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.TokenUp != "800" || m.TokenDown != "900" {
+		t.Errorf("unexpected token mapping %+v", m)
+	}
+}
+
+func TestPlaceOrderRejectsBelowMinimumSize(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("order below the minimum size should not be sent")
+	})
+
+	if _, err := c.PlaceOrder(ctx, "0xcond", SideUp, DirectionBuy, 4.99, 0.5, OrderOptions{}); err == nil {
+		t.Error("expected error for sub-minimum size")
+	}
+}
@@ -1,7 +1,8 @@
 package exchange
 
 import (
-	"errors"
+	"context"
+	"fmt"
 	"math/rand"
 	"time"
 )
@@ -9,7 +10,24 @@ import (
 // MockExchange simulates a market for testing/backtesting
 type MockExchange struct {
 	CurrentTicker *Ticker
+	Info          *MarketInfo
 	Time          time.Time
+
+	// Spread puts the best bid this far below the ask (the ticker only carries asks)
+	Spread float64
+
+	// Depth is the number of shares offered at the ask on each side per price update;
+	// 0 means unlimited
+	Depth float64
+
+	// Wallet is the simulated funder; orders it cannot pay for are rejected
+	Wallet *MockWallet
+
+	orders      map[string]*Order
+	trades      []*Trade // Ledger of every fill, oldest first
+	nextOrderID int
+	nextTradeID int
+	events      chan *UserEvent // Created by UserEvents
 }
 
 func NewMockExchange() *MockExchange {
@@ -20,43 +38,413 @@ func NewMockExchange() *MockExchange {
 			PriceUp:   0.50,
 			PriceDown: 0.50,
 		},
+		Info: &MarketInfo{
+			MarketID:        "mock-market",
+			TickSize:        AmountFromFloat(0.01),
+			MinOrderSize:    AmountFromFloat(5),
+			AcceptingOrders: true,
+		},
+		Wallet: &MockWallet{
+			Collateral: AmountFromFloat(1000),
+			Shares:     make(map[MockHolding]Amount),
+			Allowance:  MaxAmount,
+		},
+		orders: make(map[string]*Order),
+	}
+}
+
+// MockWallet holds the simulated balances. Fills move collateral and shares, and
+// open orders reserve what they may still spend, as on the CLOB.
+type MockWallet struct {
+	Collateral Amount
+	Shares     map[MockHolding]Amount
+	Allowance  Amount // Approved for the exchange, for collateral and tokens alike
+}
+
+// MockHolding identifies the shares of one outcome of a market in a MockWallet
+type MockHolding struct {
+	MarketID string
+	Side     Side
+}
+
+// balance returns the wallet's holding of the asset an order spends
+func (w *MockWallet) balance(o *Order) Amount {
+	if o.Direction == DirectionSell {
+		return w.Shares[MockHolding{o.MarketID, o.Side}]
+	}
+	return w.Collateral
+}
+
+// reserved returns what an open order may still spend: USDC for a BUY, shares for a SELL
+func reserved(o *Order) Amount {
+	remaining := AmountFromFloat(o.Size - o.SizeMatched)
+	if o.Direction == DirectionSell {
+		return remaining
+	}
+	return AmountFromFloat(o.Price).Mul(remaining, RoundUp)
+}
+
+// affordable checks that the wallet covers a new order on top of the open ones
+func (m *MockExchange) affordable(o *Order) error {
+	spend := reserved(o)
+	for _, open := range m.orders {
+		if !open.Status.IsFinal() && open.Direction == o.Direction &&
+			(o.Direction == DirectionBuy || (open.MarketID == o.MarketID && open.Side == o.Side)) {
+			spend += reserved(open)
+		}
+	}
+
+	b := m.Wallet.balance(o)
+	if spend > b || spend > m.Wallet.Allowance {
+		return fmt.Errorf("%w: order needs %s, wallet has %s (allowance %s)", ErrInsufficientBalance, spend, b, m.Wallet.Allowance)
+	}
+	return nil
+}
+
+// settle moves a fill of qty shares at px through the wallet
+func (m *MockExchange) settle(o *Order, px, qty float64) {
+	if m.Wallet == nil {
+		return
+	}
+	if m.Wallet.Shares == nil {
+		m.Wallet.Shares = make(map[MockHolding]Amount)
+	}
+	holding := MockHolding{o.MarketID, o.Side}
+	shares := AmountFromFloat(qty)
+	if o.Direction == DirectionSell {
+		m.Wallet.Shares[holding] -= shares
+		m.Wallet.Collateral += fillValue(o.Direction, px, qty)
+		return
 	}
+	m.Wallet.Shares[holding] += shares
+	m.Wallet.Collateral -= fillValue(o.Direction, px, qty)
 }
 
-func (m *MockExchange) GetTicker(marketID string) (*Ticker, error) {
+// fillValue returns the USDC of a fill, rounded in the exchange's favour
+func fillValue(dir Direction, px, qty float64) Amount {
+	if dir == DirectionSell {
+		return AmountFromFloat(px).Mul(AmountFromFloat(qty), RoundDown)
+	}
+	return AmountFromFloat(px).Mul(AmountFromFloat(qty), RoundUp)
+}
+
+func (m *MockExchange) GetTicker(ctx context.Context, marketID string) (*Ticker, error) {
+	m.CurrentTicker.MarketID = marketID
 	m.CurrentTicker.Timestamp = m.Time
 	return m.CurrentTicker, nil
 }
 
-func (m *MockExchange) PlaceOrder(marketID string, side Side, size float64, price float64) (*Order, error) {
-	// Simulate immediate fill at requested price
+func (m *MockExchange) PlaceOrder(ctx context.Context, marketID string, side Side, dir Direction, size float64, price float64, opts OrderOptions) (*Order, error) {
 	if size <= 0 {
-		return nil, errors.New("invalid size")
+		return nil, fmt.Errorf("%w: invalid size", ErrOrderRejected)
+	}
+	orderType := opts.orderType()
+	if orderType == OrderTypeGTD && !opts.Expiration.After(m.Time) {
+		return nil, fmt.Errorf("%w: GTD expiration must be in the future", ErrOrderRejected)
+	}
+
+	maker, taker := orderAmounts(dir, orderType, price, size, m.Info.TickSize)
+	shares := taker
+	if dir == DirectionSell {
+		shares = maker
+	}
+	if shares < m.Info.MinOrderSize {
+		return nil, fmt.Errorf("%w: size below minimum order size", ErrOrderRejected)
 	}
 
-	return &Order{
-		ID:        "mock-order-id",
-		MarketID:  marketID,
-		Side:      side,
-		Price:     price,
-		Size:      size,
+	m.nextOrderID++
+	order := &Order{
+		ID:          fmt.Sprintf("mock-order-%d", m.nextOrderID),
+		ClientID:    opts.ClientID,
+		MarketID:    marketID,
+		Side:        side,
+		Direction:   dir,
+		Price:       price,
+		Size:        size,
+		Timestamp:   m.Time,
+		Type:        orderType,
+		Status:      OrderLive,
+		MakerAmount: maker,
+		TakerAmount: taker,
+	}
+	if order.ClientID == "" {
+		order.ClientID = order.ID
+	}
+	if orderType == OrderTypeGTD {
+		order.Expiration = opts.Expiration
+	}
+	if m.Wallet != nil {
+		if err := m.affordable(order); err != nil {
+			return nil, err
+		}
+	}
+
+	switch orderType {
+	case OrderTypeFOK:
+		if m.fillable(order) < order.Size {
+			return nil, fmt.Errorf("%w: FOK order not fully fillable", ErrNotFilled)
+		}
+		m.matchOrder(order)
+	case OrderTypeFAK:
+		if m.fillable(order) == 0 {
+			return nil, fmt.Errorf("%w: no liquidity at the FAK limit price", ErrNotFilled)
+		}
+		m.matchOrder(order)
+		if !order.Status.IsFinal() {
+			order.Status = OrderCancelled // The remainder never rests
+		}
+	default:
+		// Marketable orders fill immediately, the rest rest on the book
+		m.matchOrder(order)
+	}
+
+	m.orders[order.ID] = order
+	m.orderEvent(order)
+	return order.copy(), nil
+}
+
+// matchPrice returns the price the order would trade at: the ask for a BUY, the bid for a SELL
+func (m *MockExchange) matchPrice(o *Order) (float64, bool) {
+	ask := m.CurrentTicker.PriceUp
+	if o.Side == SideDown {
+		ask = m.CurrentTicker.PriceDown
+	}
+	if o.Direction == DirectionSell {
+		bid := ask - m.Spread
+		return bid, o.Price <= bid
+	}
+	return ask, o.Price >= ask
+}
+
+// fillable returns how many shares of the order would match right now
+func (m *MockExchange) fillable(o *Order) float64 {
+	if _, ok := m.matchPrice(o); !ok {
+		return 0
+	}
+
+	remaining := o.Size - o.SizeMatched
+	if m.Depth > 0 && m.Depth < remaining {
+		return m.Depth
+	}
+	return remaining
+}
+
+// matchOrder fills a working order at the current ask (BUY) or bid (SELL) when its
+// limit crosses it, up to the available depth, reporting whether it filled
+func (m *MockExchange) matchOrder(o *Order) bool {
+	if o.Status.IsFinal() {
+		return false
+	}
+	qty := m.fillable(o)
+	if qty == 0 {
+		return false
+	}
+
+	px, _ := m.matchPrice(o)
+	o.AvgPrice = (o.AvgPrice*o.SizeMatched + px*qty) / (o.SizeMatched + qty)
+	o.SizeMatched += qty
+	o.MatchedCost += fillValue(o.Direction, px, qty)
+	m.settle(o, px, qty)
+
+	if o.SizeMatched >= o.Size {
+		o.Status = OrderMatched
+	} else {
+		o.Status = OrderPartiallyFilled
+	}
+
+	// Trades settle instantly in the simulation
+	m.nextTradeID++
+	m.trades = append(m.trades, &Trade{
+		ID:        fmt.Sprintf("mock-trade-%d", m.nextTradeID),
+		MarketID:  o.MarketID,
+		OrderID:   o.ID,
+		Side:      o.Side,
+		Direction: o.Direction,
+		Price:     px,
+		Size:      qty,
+		Status:    TradeConfirmed,
 		Timestamp: m.Time,
-	}, nil
+	})
+	for _, status := range []TradeStatus{TradeMatched, TradeConfirmed} {
+		m.emit(&UserEvent{
+			Type:        UserEventTrade,
+			MarketID:    o.MarketID,
+			OrderID:     o.ID,
+			Side:        o.Side,
+			Direction:   o.Direction,
+			Price:       px,
+			Timestamp:   m.Time,
+			TradeID:     fmt.Sprintf("mock-trade-%d", m.nextTradeID),
+			TradeStatus: status,
+			Size:        qty,
+		})
+	}
+	return true
+}
+
+func (m *MockExchange) GetOrder(ctx context.Context, orderID string) (*Order, error) {
+	o, ok := m.orders[orderID]
+	if !ok {
+		return nil, fmt.Errorf("%w: order %s", ErrNotFound, orderID)
+	}
+	return o.copy(), nil
+}
+
+func (m *MockExchange) CancelOrder(ctx context.Context, orderID string) error {
+	o, ok := m.orders[orderID]
+	if !ok {
+		return fmt.Errorf("%w: order %s", ErrNotFound, orderID)
+	}
+	if o.Status.IsFinal() {
+		return fmt.Errorf("order %s is %s", orderID, o.Status)
+	}
+	o.Status = OrderCancelled
+	m.orderEvent(o)
+	return nil
+}
+
+func (m *MockExchange) CancelAll(ctx context.Context) error {
+	for _, o := range m.orders {
+		if !o.Status.IsFinal() {
+			o.Status = OrderCancelled
+			m.orderEvent(o)
+		}
+	}
+	return nil
+}
+
+func (m *MockExchange) OpenOrders(ctx context.Context, marketID string) ([]*Order, error) {
+	var open []*Order
+	for _, o := range m.orders {
+		if o.MarketID == marketID && !o.Status.IsFinal() {
+			open = append(open, o.copy())
+		}
+	}
+	return open, nil
+}
+
+func (m *MockExchange) GetMarketInfo(ctx context.Context, marketID string) (*MarketInfo, error) {
+	return m.Info, nil
+}
+
+func (m *MockExchange) GetCollateralBalance(ctx context.Context, marketID string) (*Balance, error) {
+	if m.Wallet == nil {
+		return &Balance{Asset: AssetCollateral, Balance: MaxAmount, Allowance: MaxAmount}, nil
+	}
+	return &Balance{Asset: AssetCollateral, Balance: m.Wallet.Collateral, Allowance: m.Wallet.Allowance}, nil
+}
+
+func (m *MockExchange) GetTokenBalance(ctx context.Context, marketID string, side Side) (*Balance, error) {
+	if m.Wallet == nil {
+		return &Balance{Asset: AssetConditional, TokenID: string(side), Balance: MaxAmount, Allowance: MaxAmount}, nil
+	}
+	return &Balance{Asset: AssetConditional, TokenID: string(side), Balance: m.Wallet.Shares[MockHolding{marketID, side}], Allowance: m.Wallet.Allowance}, nil
+}
+
+func (m *MockExchange) GetTrades(ctx context.Context, marketID string) ([]*Trade, error) {
+	var trades []*Trade
+	for _, t := range m.trades {
+		if t.MarketID == marketID {
+			cp := *t
+			trades = append(trades, &cp)
+		}
+	}
+	return trades, nil
+}
+
+// GetPositions replays the ledger; sells reduce a holding at its average price
+func (m *MockExchange) GetPositions(ctx context.Context, marketID string) ([]*Position, error) {
+	var positions []*Position
+	held := make(map[string]*Position)
+	for _, t := range m.trades {
+		if marketID != "" && t.MarketID != marketID {
+			continue
+		}
+		key := t.MarketID + "/" + string(t.Side)
+		p, ok := held[key]
+		if !ok {
+			p = &Position{MarketID: t.MarketID, Side: t.Side, TokenID: string(t.Side)}
+			held[key] = p
+			positions = append(positions, p)
+		}
+		if t.Direction == DirectionSell {
+			p.Size -= t.Size
+			continue
+		}
+		p.AvgPrice = (p.AvgPrice*p.Size + t.Price*t.Size) / (p.Size + t.Size)
+		p.Size += t.Size
+	}
+
+	open := positions[:0]
+	for _, p := range positions {
+		if p.Size > 0 {
+			open = append(open, p)
+		}
+	}
+	return open, nil
 }
 
 func (m *MockExchange) CurrentTime() time.Time {
 	return m.Time
 }
 
+// UserEvents implements UserEventSource; events are only recorded once it has been called
+func (m *MockExchange) UserEvents() <-chan *UserEvent {
+	if m.events == nil {
+		m.events = make(chan *UserEvent, 1024)
+	}
+	return m.events
+}
+
+// emit publishes an event, dropping it when nobody keeps up
+func (m *MockExchange) emit(ev *UserEvent) {
+	if m.events == nil {
+		return
+	}
+	select {
+	case m.events <- ev:
+	default:
+	}
+}
+
+// orderEvent publishes the current state of an order
+func (m *MockExchange) orderEvent(o *Order) {
+	m.emit(&UserEvent{
+		Type:        UserEventOrder,
+		MarketID:    o.MarketID,
+		OrderID:     o.ID,
+		Side:        o.Side,
+		Direction:   o.Direction,
+		Price:       o.Price,
+		Timestamp:   m.Time,
+		OrderStatus: o.Status,
+		SizeMatched: o.SizeMatched,
+	})
+}
+
 // Helpers to manipulate simulation
 
 func (m *MockExchange) SetPrice(up, down float64) {
 	m.CurrentTicker.PriceUp = up
 	m.CurrentTicker.PriceDown = down
+
+	for _, o := range m.orders {
+		if m.matchOrder(o) {
+			m.orderEvent(o)
+		}
+	}
 }
 
 func (m *MockExchange) AdvanceTime(d time.Duration) {
 	m.Time = m.Time.Add(d)
+
+	// GTD orders expire once their expiration passes
+	for _, o := range m.orders {
+		if !o.Status.IsFinal() && !o.Expiration.IsZero() && !m.Time.Before(o.Expiration) {
+			o.Status = OrderExpired
+			m.orderEvent(o)
+		}
+	}
 }
 
 func (m *MockExchange) SimulateDump(side Side, from, to float64, duration time.Duration) {
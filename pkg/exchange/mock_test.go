@@ -0,0 +1,194 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMockRestingOrderLifecycle(t *testing.T) {
+	ctx := context.Background()
+	m := NewMockExchange()
+	m.SetPrice(0.50, 0.50)
+
+	// Below the ask: rests on the book
+	order, err := m.PlaceOrder(ctx, "mock-market", SideUp, DirectionBuy, 10, 0.40, OrderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != OrderLive {
+		t.Fatalf("expected live order, got %s", order.Status)
+	}
+	if open, _ := m.OpenOrders(ctx, "mock-market"); len(open) != 1 {
+		t.Errorf("expected 1 open order, got %d", len(open))
+	}
+
+	// Ask drops through the limit: fills at the ask
+	m.SetPrice(0.38, 0.60)
+	order, _ = m.GetOrder(ctx, order.ID)
+	if order.Status != OrderMatched || order.AvgPrice != 0.38 || order.SizeMatched != 10 {
+		t.Errorf("expected fill at 0.38, got %+v", order)
+	}
+	if err := m.CancelOrder(ctx, order.ID); err == nil {
+		t.Error("cancelling a matched order should fail")
+	}
+
+	// A second resting order gets pulled by CancelAll
+	order, _ = m.PlaceOrder(ctx, "mock-market", SideDown, DirectionBuy, 10, 0.30, OrderOptions{})
+	m.CancelAll(ctx)
+	order, _ = m.GetOrder(ctx, order.ID)
+	if order.Status != OrderCancelled {
+		t.Errorf("expected cancelled order, got %s", order.Status)
+	}
+}
+
+func TestMockOrderTypes(t *testing.T) {
+	ctx := context.Background()
+	m := NewMockExchange()
+	m.SetPrice(0.40, 0.60)
+	m.Depth = 6
+
+	// FOK: 10 shares can't fill against 6 offered
+	if _, err := m.PlaceOrder(ctx, "mock-market", SideUp, DirectionBuy, 10, 0.40, OrderOptions{Type: OrderTypeFOK}); err == nil {
+		t.Error("expected FOK order to be killed")
+	}
+	order, err := m.PlaceOrder(ctx, "mock-market", SideUp, DirectionBuy, 6, 0.40, OrderOptions{Type: OrderTypeFOK})
+	if err != nil || order.Status != OrderMatched {
+		t.Errorf("expected FOK fill, got %+v (err %v)", order, err)
+	}
+
+	// FAK: takes the 6 offered, kills the rest
+	order, err = m.PlaceOrder(ctx, "mock-market", SideUp, DirectionBuy, 10, 0.40, OrderOptions{Type: OrderTypeFAK})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != OrderCancelled || order.SizeMatched != 6 {
+		t.Errorf("expected FAK partial fill of 6, got %+v", order)
+	}
+	if _, err := m.PlaceOrder(ctx, "mock-market", SideUp, DirectionBuy, 10, 0.30, OrderOptions{Type: OrderTypeFAK}); err == nil {
+		t.Error("expected non-marketable FAK order to be killed")
+	}
+
+	// GTD: rests until its expiration passes
+	order, err = m.PlaceOrder(ctx, "mock-market", SideDown, DirectionBuy, 10, 0.50, OrderOptions{Type: OrderTypeGTD, Expiration: m.Time.Add(2 * time.Minute)})
+	if err != nil || order.Status != OrderLive {
+		t.Fatalf("expected live GTD order, got %+v (err %v)", order, err)
+	}
+	m.AdvanceTime(2 * time.Minute)
+	order, _ = m.GetOrder(ctx, order.ID)
+	if order.Status != OrderExpired {
+		t.Errorf("expected expired GTD order, got %s", order.Status)
+	}
+}
+
+func TestMockSellOrder(t *testing.T) {
+	ctx := context.Background()
+	m := NewMockExchange()
+	m.SetPrice(0.50, 0.50)
+	m.Spread = 0.02
+	m.Wallet.Shares[MockHolding{"mock-market", SideUp}] = AmountFromFloat(20)
+
+	// The best bid is 0.48: a sell at 0.49 rests, one at 0.48 fills
+	order, err := m.PlaceOrder(ctx, "mock-market", SideUp, DirectionSell, 10, 0.49, OrderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != OrderLive {
+		t.Errorf("expected resting sell, got %s", order.Status)
+	}
+
+	order, err = m.PlaceOrder(ctx, "mock-market", SideUp, DirectionSell, 10, 0.45, OrderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != OrderMatched || order.AvgPrice != 0.48 || order.Direction != DirectionSell {
+		t.Errorf("expected sell filled at the 0.48 bid, got %+v", order)
+	}
+}
+
+func TestMockWallet(t *testing.T) {
+	ctx := context.Background()
+	m := NewMockExchange()
+	m.Wallet.Collateral = AmountFromFloat(10)
+	m.SetPrice(0.50, 0.50)
+
+	// A resting bid reserves its cost
+	if _, err := m.PlaceOrder(ctx, "mock-market", SideUp, DirectionBuy, 10, 0.40, OrderOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.PlaceOrder(ctx, "mock-market", SideDown, DirectionBuy, 14, 0.50, OrderOptions{}); !errors.Is(err, ErrInsufficientBalance) {
+		t.Errorf("expected ErrInsufficientBalance, got %v", err)
+	}
+
+	// Fills move collateral into shares
+	m.CancelAll(ctx)
+	if _, err := m.PlaceOrder(ctx, "mock-market", SideDown, DirectionBuy, 10, 0.50, OrderOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	usdc, _ := m.GetCollateralBalance(ctx, "mock-market")
+	shares, _ := m.GetTokenBalance(ctx, "mock-market", SideDown)
+	if usdc.Balance != AmountFromFloat(5) || shares.Balance != AmountFromFloat(10) {
+		t.Errorf("expected 5 USDC and 10 DOWN shares, got %s and %s", usdc.Balance, shares.Balance)
+	}
+	if other, _ := m.GetTokenBalance(ctx, "other-market", SideDown); other.Balance != 0 {
+		t.Errorf("expected no DOWN shares of another market, got %s", other.Balance)
+	}
+
+	// Shares cannot be sold twice, nor spent beyond the allowance
+	if _, err := m.PlaceOrder(ctx, "mock-market", SideDown, DirectionSell, 11, 0.60, OrderOptions{}); !errors.Is(err, ErrInsufficientBalance) {
+		t.Errorf("expected the oversized sell to be rejected, got %v", err)
+	}
+	m.Wallet.Allowance = AmountFromFloat(1)
+	if _, err := m.PlaceOrder(ctx, "mock-market", SideUp, DirectionBuy, 5, 0.40, OrderOptions{}); !errors.Is(err, ErrInsufficientBalance) {
+		t.Errorf("expected the allowance to cap spending, got %v", err)
+	}
+}
+
+func TestMockLedger(t *testing.T) {
+	ctx := context.Background()
+	m := NewMockExchange()
+	m.SetPrice(0.40, 0.60)
+
+	m.PlaceOrder(ctx, "mock-market", SideUp, DirectionBuy, 10, 0.40, OrderOptions{})
+	m.SetPrice(0.50, 0.50)
+	m.PlaceOrder(ctx, "mock-market", SideUp, DirectionBuy, 10, 0.50, OrderOptions{})
+	m.PlaceOrder(ctx, "mock-market", SideUp, DirectionSell, 5, 0.50, OrderOptions{})
+
+	trades, _ := m.GetTrades(ctx, "mock-market")
+	if len(trades) != 3 || trades[2].Direction != DirectionSell || trades[2].Status != TradeConfirmed {
+		t.Fatalf("unexpected ledger %+v", trades)
+	}
+
+	positions, _ := m.GetPositions(ctx, "")
+	if len(positions) != 1 {
+		t.Fatalf("expected one position, got %d", len(positions))
+	}
+	if p := positions[0]; p.Side != SideUp || p.Size != 15 || math.Abs(p.AvgPrice-0.45) > 1e-9 {
+		t.Errorf("expected 15 UP @ 0.45, got %+v", p)
+	}
+}
+
+func TestMockUserEvents(t *testing.T) {
+	ctx := context.Background()
+	m := NewMockExchange()
+	events := m.UserEvents()
+	m.SetPrice(0.50, 0.50)
+
+	order, _ := m.PlaceOrder(ctx, "mock-market", SideUp, DirectionBuy, 10, 0.40, OrderOptions{})
+	if ev := <-events; ev.Type != UserEventOrder || ev.OrderStatus != OrderLive {
+		t.Errorf("placement = %+v", ev)
+	}
+
+	m.SetPrice(0.38, 0.60)
+	for _, want := range []TradeStatus{TradeMatched, TradeConfirmed} {
+		ev := <-events
+		if ev.Type != UserEventTrade || ev.TradeStatus != want || ev.OrderID != order.ID || ev.Size != 10 || ev.Price != 0.38 {
+			t.Errorf("trade = %+v, want %s", ev, want)
+		}
+	}
+	if ev := <-events; ev.OrderStatus != OrderMatched || ev.SizeMatched != 10 {
+		t.Errorf("order update = %+v", ev)
+	}
+}
@@ -0,0 +1,176 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// SignatureType identifies how the order signer relates to the maker (funder)
+type SignatureType uint8
+
+const (
+	SignatureEOA        SignatureType = 0 // Maker is the signing EOA itself
+	SignaturePolyProxy  SignatureType = 1 // Maker is a Polymarket proxy wallet owned by the EOA
+	SignatureGnosisSafe SignatureType = 2 // Maker is a Gnosis Safe owned by the EOA
+)
+
+func (t SignatureType) String() string {
+	switch t {
+	case SignatureEOA:
+		return "EOA"
+	case SignaturePolyProxy:
+		return "POLY_PROXY"
+	case SignatureGnosisSafe:
+		return "POLY_GNOSIS_SAFE"
+	}
+	return fmt.Sprintf("SignatureType(%d)", uint8(t))
+}
+
+// Polymarket order side: BUY=0, SELL=1
+const (
+	orderSideBuy  uint8 = 0
+	orderSideSell uint8 = 1
+)
+
+// orderData holds the fields of the CTF Exchange Order struct
+type orderData struct {
+	Salt          *big.Int
+	Maker         common.Address
+	Signer        common.Address
+	Taker         common.Address
+	TokenID       *big.Int
+	MakerAmount   *big.Int
+	TakerAmount   *big.Int
+	Expiration    *big.Int
+	Nonce         *big.Int
+	FeeRateBps    *big.Int
+	Side          uint8
+	SignatureType SignatureType
+}
+
+// signedOrder is the order as sent to POST /order
+type signedOrder struct {
+	Salt          int64  `json:"salt"`
+	Maker         string `json:"maker"`
+	Signer        string `json:"signer"`
+	Taker         string `json:"taker"`
+	TokenID       string `json:"tokenId"`
+	MakerAmount   string `json:"makerAmount"`
+	TakerAmount   string `json:"takerAmount"`
+	Expiration    string `json:"expiration"`
+	Nonce         string `json:"nonce"`
+	FeeRateBps    string `json:"feeRateBps"`
+	Side          string `json:"side"` // The API expects the side as a string
+	SignatureType int    `json:"signatureType"`
+	Signature     string `json:"signature"`
+}
+
+// makerAddress returns the address whose funds back the order. For EOA signatures
+// the funder must be the signing key itself; proxies and Safes are owned by it.
+func (c *PolymarketClient) makerAddress() (common.Address, error) {
+	signer := c.signerAddress()
+
+	switch c.SignatureType {
+	case SignatureEOA:
+		if c.Funder != (common.Address{}) && c.Funder != signer {
+			return common.Address{}, fmt.Errorf("funder %s differs from signer %s; use a proxy or safe signature type", c.Funder.Hex(), signer.Hex())
+		}
+		return signer, nil
+	case SignaturePolyProxy, SignatureGnosisSafe:
+		if c.Funder == (common.Address{}) {
+			return common.Address{}, fmt.Errorf("%s signature type requires a funder address", c.SignatureType)
+		}
+		return c.Funder, nil
+	}
+	return common.Address{}, fmt.Errorf("unsupported signature type %d", c.SignatureType)
+}
+
+// orderTypedData builds the EIP-712 payload of an order for the given exchange contract
+func (c *PolymarketClient) orderTypedData(o *orderData, exchangeAddr string) apitypes.TypedData {
+	domain := apitypes.TypedDataDomain{
+		Name:              "Polymarket CTF Exchange",
+		Version:           "1",
+		ChainId:           math.NewHexOrDecimal256(c.ChainID),
+		VerifyingContract: exchangeAddr,
+	}
+
+	types := apitypes.Types{
+		"EIP712Domain": {
+			{Name: "name", Type: "string"},
+			{Name: "version", Type: "string"},
+			{Name: "chainId", Type: "uint256"},
+			{Name: "verifyingContract", Type: "address"},
+		},
+		"Order": {
+			{Name: "salt", Type: "uint256"},
+			{Name: "maker", Type: "address"},
+			{Name: "signer", Type: "address"},
+			{Name: "taker", Type: "address"},
+			{Name: "tokenId", Type: "uint256"},
+			{Name: "makerAmount", Type: "uint256"},
+			{Name: "takerAmount", Type: "uint256"},
+			{Name: "expiration", Type: "uint256"},
+			{Name: "nonce", Type: "uint256"},
+			{Name: "feeRateBps", Type: "uint256"},
+			{Name: "side", Type: "uint8"},
+			{Name: "signatureType", Type: "uint8"},
+		},
+	}
+
+	message := apitypes.TypedDataMessage{
+		"salt":          o.Salt.String(),
+		"maker":         o.Maker.Hex(),
+		"signer":        o.Signer.Hex(),
+		"taker":         o.Taker.Hex(),
+		"tokenId":       o.TokenID.String(),
+		"makerAmount":   o.MakerAmount.String(),
+		"takerAmount":   o.TakerAmount.String(),
+		"expiration":    o.Expiration.String(),
+		"nonce":         o.Nonce.String(),
+		"feeRateBps":    o.FeeRateBps.String(),
+		"side":          fmt.Sprintf("%d", o.Side),
+		"signatureType": fmt.Sprintf("%d", o.SignatureType),
+	}
+
+	return apitypes.TypedData{
+		Types:       types,
+		PrimaryType: "Order",
+		Domain:      domain,
+		Message:     message,
+	}
+}
+
+// signOrder signs the order with the EOA key and returns the API representation
+func (c *PolymarketClient) signOrder(ctx context.Context, o *orderData, exchangeAddr string) (*signedOrder, error) {
+	signature, err := c.signTypedData(ctx, c.orderTypedData(o, exchangeAddr))
+	if err != nil {
+		return nil, fmt.Errorf("signing failed: %w", err)
+	}
+
+	side := "BUY"
+	if o.Side == orderSideSell {
+		side = "SELL"
+	}
+
+	return &signedOrder{
+		Salt:          o.Salt.Int64(),
+		Maker:         o.Maker.Hex(),
+		Signer:        o.Signer.Hex(),
+		Taker:         o.Taker.Hex(),
+		TokenID:       o.TokenID.String(),
+		MakerAmount:   o.MakerAmount.String(),
+		TakerAmount:   o.TakerAmount.String(),
+		Expiration:    o.Expiration.String(),
+		Nonce:         o.Nonce.String(),
+		FeeRateBps:    o.FeeRateBps.String(),
+		Side:          side,
+		SignatureType: int(o.SignatureType),
+		Signature:     hexutil.Encode(signature),
+	}, nil
+}
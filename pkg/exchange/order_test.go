@@ -0,0 +1,105 @@
+package exchange
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"poly/pkg/signer"
+)
+
+// Well-known test key (hardhat account #0)
+const testPrivateKey = "0xac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+
+func TestSignOrderSignatureTypes(t *testing.T) {
+	eoa := common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266")
+	proxy := common.HexToAddress("0x1111111111111111111111111111111111111111")
+
+	cases := []struct {
+		sigType   SignatureType
+		funder    common.Address
+		wantMaker common.Address
+		wantSig   string // RFC 6979 signatures are deterministic
+	}{
+		{SignatureEOA, eoa, eoa, "0x9590b62c663dd021cbd1002941d302e8f38590675f9865764aabc2494018fb0e068ecfeae77bebe7b3f1c629c30517b631234b3a1875f723230ee50cfb8584a91c"},
+		{SignaturePolyProxy, proxy, proxy, "0x4258846bcd0d2befb4985a974bbe37caa3d43e3c4849760cf52191cebc55d17f315551acee3a38740fb8bd926c44d7cd533223719dbe539324db3977933c26a01b"},
+		{SignatureGnosisSafe, proxy, proxy, "0x0593d90c8361448882f34d3d393aade9c4e94624c58d9d4771b485d4aa255af817a059ff3149a903cf1ef884b768762c61c043df4070529447d66c83e174d4831c"},
+	}
+
+	for _, tc := range cases {
+		c, err := NewPolymarketClient("k", testSecret, "p", testPrivateKey, tc.funder.Hex())
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.SignatureType = tc.sigType
+
+		maker, err := c.makerAddress()
+		if err != nil {
+			t.Fatalf("%s: %v", tc.sigType, err)
+		}
+		o := &orderData{
+			Salt:          big.NewInt(479249096354),
+			Maker:         maker,
+			Signer:        c.signerAddress(),
+			TokenID:       big.NewInt(1234),
+			MakerAmount:   big.NewInt(4500000),
+			TakerAmount:   big.NewInt(10000000),
+			Expiration:    big.NewInt(0),
+			Nonce:         big.NewInt(0),
+			FeeRateBps:    big.NewInt(0),
+			Side:          orderSideBuy,
+			SignatureType: tc.sigType,
+		}
+
+		signed, err := c.signOrder(context.Background(), o, CTFExchangeAddress)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if signed.Maker != tc.wantMaker.Hex() || signed.Signer != eoa.Hex() {
+			t.Errorf("%s: maker %s signer %s", tc.sigType, signed.Maker, signed.Signer)
+		}
+		if signed.Signature != tc.wantSig {
+			t.Errorf("%s: signature %s, want %s", tc.sigType, signed.Signature, tc.wantSig)
+		}
+		if signed.SignatureType != int(tc.sigType) {
+			t.Errorf("%s: signatureType %d", tc.sigType, signed.SignatureType)
+		}
+
+		// The signature must recover to the EOA, whatever the maker is
+		hash, err := signer.TypedDataHash(c.orderTypedData(o, CTFExchangeAddress))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig := hexutil.MustDecode(signed.Signature)
+		sig[64] -= 27
+		pub, err := crypto.SigToPub(hash, sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if crypto.PubkeyToAddress(*pub) != eoa {
+			t.Errorf("%s: signature recovers to %s", tc.sigType, crypto.PubkeyToAddress(*pub).Hex())
+		}
+	}
+}
+
+func TestMakerAddressValidation(t *testing.T) {
+	c, err := NewPolymarketClient("k", testSecret, "p", testPrivateKey, "0x1111111111111111111111111111111111111111")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An EOA can't sign for someone else's funds
+	if _, err := c.makerAddress(); err == nil {
+		t.Error("expected error for EOA signature with a foreign funder")
+	}
+
+	c.SignatureType = SignaturePolyProxy
+	c.Funder = common.Address{}
+	if _, err := c.makerAddress(); err == nil {
+		t.Error("expected error for proxy signature without funder")
+	}
+}
@@ -2,74 +2,120 @@ package exchange
 
 import (
 	"bytes"
-	"crypto/ecdsa"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
-	"github.com/ethereum/go-ethereum/common/math"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+
+	"poly/pkg/signer"
 )
 
 // PolymarketClient is the implementation for interacting with Polymarket CLOB
 type PolymarketClient struct {
 	BaseURL    string
+	DataURL    string // Data API serving positions, DataAPIURL when empty
 	APIKey     string
 	APISecret  string
 	Passphrase string
-	PrivateKey *ecdsa.PrivateKey
+	Signer     signer.Signer // Signs orders and L1 auth; may be remote, never exposing the key
 	ChainID    int64
 	Client     *http.Client
 	Funder     common.Address // The address holding the funds (Proxy or EOA)
+
+	// SignatureType selects how orders are signed: by the funder EOA itself,
+	// or by the EOA on behalf of a Polymarket proxy wallet or Gnosis Safe.
+	SignatureType SignatureType
+
+	Limiter *RateLimiter // nil disables rate limiting
+	Retry   RetryPolicy
+
+	Orders *OrderIdentities // Salts, nonces and client ids of the signed orders
+
+	mu      sync.RWMutex
+	markets map[string]*Market
+	infos   map[string]*MarketInfo
 }
 
+// NewPolymarketClient creates a client signing with a hex private key; see
+// NewPolymarketClientWithSigner to keep the key out of the process
 func NewPolymarketClient(key, secret, passphrase, privateKeyHex string, funderAddr string) (*PolymarketClient, error) {
-	pk, err := crypto.HexToECDSA(strings.TrimPrefix(privateKeyHex, "0x"))
+	s, err := signer.FromHex(privateKeyHex)
 	if err != nil {
-		return nil, fmt.Errorf("invalid private key: %v", err)
+		return nil, err
 	}
+	return NewPolymarketClientWithSigner(key, secret, passphrase, s, funderAddr), nil
+}
 
+// NewPolymarketClientWithSigner creates a client signing with s, e.g. a keystore,
+// mnemonic or remote signer
+func NewPolymarketClientWithSigner(key, secret, passphrase string, s signer.Signer, funderAddr string) *PolymarketClient {
 	return &PolymarketClient{
-		BaseURL:    "https://clob.polymarket.com",
-		APIKey:     key,
-		APISecret:  secret,
-		Passphrase: passphrase,
-		PrivateKey: pk,
-		ChainID:    137, // Polygon Mainnet
-		Client:     &http.Client{Timeout: 10 * time.Second},
-		Funder:     common.HexToAddress(funderAddr),
-	}, nil
+		BaseURL:       "https://clob.polymarket.com",
+		APIKey:        key,
+		APISecret:     secret,
+		Passphrase:    passphrase,
+		Signer:        s,
+		ChainID:       137, // Polygon Mainnet
+		Client:        &http.Client{Timeout: 10 * time.Second},
+		Funder:        common.HexToAddress(funderAddr),
+		SignatureType: SignatureEOA,
+		Limiter:       DefaultRateLimiter,
+		Retry:         DefaultRetryPolicy,
+		Orders:        NewOrderIdentities(),
+		markets:       make(map[string]*Market),
+		infos:         make(map[string]*MarketInfo),
+	}
 }
 
-// GetTicker fetches the orderbook summary to get best bid/ask
-func (c *PolymarketClient) GetTicker(marketID string) (*Ticker, error) {
-	// Endpoint: GET /book?token_id={marketID}
-	// marketID here is expected to be the Token ID for the outcome we are watching.
-
-	ob, err := c.getOrderBook(marketID)
+// GetTicker fetches the UP and DOWN order books concurrently and returns both best asks
+func (c *PolymarketClient) GetTicker(ctx context.Context, marketID string) (*Ticker, error) {
+	m, err := c.Market(ctx, marketID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Parse best bid/ask
-	bestAsk := 0.0
-	if len(ob.Asks) > 0 {
-		p, _ := strconv.ParseFloat(ob.Asks[0].Price, 64)
-		bestAsk = p
+	// Endpoint: GET /book?token_id={tokenID} for each outcome
+	var wg sync.WaitGroup
+	var books [2]*OrderBookResponse
+	var errs [2]error
+	for i, tokenID := range []string{m.TokenUp, m.TokenDown} {
+		wg.Add(1)
+		go func(i int, tokenID string) {
+			defer wg.Done()
+			books[i], errs[i] = c.getOrderBook(ctx, tokenID)
+		}(i, tokenID)
+	}
+	wg.Wait()
+	now := time.Now()
+
+	var asks [2]float64
+	for i := range books {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		ask, ok := books[i].BestAsk()
+		if !ok {
+			return nil, fmt.Errorf("no asks in orderbook for market %s", marketID)
+		}
+		asks[i] = ask
 	}
 
 	return &Ticker{
 		MarketID:  marketID,
-		PriceUp:   bestAsk, // Assume the queried token is UP
-		PriceDown: 0.0,     // Unknown without 2nd call
-		Timestamp: time.Now(),
+		PriceUp:   asks[0],
+		PriceDown: asks[1],
+		Timestamp: now,
 	}, nil
 }
 
@@ -84,34 +130,74 @@ type OrderBookResponse struct {
 	} `json:"bids"`
 }
 
-func (c *PolymarketClient) getOrderBook(tokenID string) (*OrderBookResponse, error) {
-	url := fmt.Sprintf("%s/book?token_id=%s", c.BaseURL, tokenID)
-	resp, err := c.Client.Get(url)
-	if err != nil {
-		return nil, err
+// BestAsk returns the lowest ask; the CLOB does not guarantee the sort order
+func (ob *OrderBookResponse) BestAsk() (float64, bool) {
+	best, found := 0.0, false
+	for _, lvl := range ob.Asks {
+		p, err := strconv.ParseFloat(lvl.Price, 64)
+		if err != nil {
+			continue
+		}
+		if !found || p < best {
+			best, found = p, true
+		}
 	}
-	defer resp.Body.Close()
+	return best, found
+}
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("failed to get orderbook: status %d", resp.StatusCode)
+func (c *PolymarketClient) getOrderBook(ctx context.Context, tokenID string) (*OrderBookResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.BaseURL+"/book?token_id="+url.QueryEscape(tokenID), nil)
+	if err != nil {
+		return nil, err
 	}
-
 	var ob OrderBookResponse
-	if err := json.NewDecoder(resp.Body).Decode(&ob); err != nil {
-		return nil, err
+	if err := c.do(req, &ob); err != nil {
+		return nil, fmt.Errorf("failed to get orderbook: %w", err)
 	}
 	return &ob, nil
 }
 
+// gtdSecurityThreshold is the margin the CLOB requires between now and a GTD expiration
+const gtdSecurityThreshold = time.Minute
+
 // PlaceOrder implements the EIP-712 signing and order placement
-func (c *PolymarketClient) PlaceOrder(tokenID string, side Side, size float64, price float64) (*Order, error) {
-	// 1. Prepare Order Data
-	// Polymarket Side: BUY=0, SELL=1.
-	polymarketSide := 0 // Always BUY for this strategy
+func (c *PolymarketClient) PlaceOrder(ctx context.Context, marketID string, side Side, dir Direction, size float64, price float64, opts OrderOptions) (*Order, error) {
+	orderType := opts.orderType()
+
+	// Only GTD orders may carry an expiration; the rest are signed with 0
+	expiration := big.NewInt(0)
+	switch orderType {
+	case OrderTypeGTD:
+		if time.Until(opts.Expiration) <= gtdSecurityThreshold {
+			return nil, fmt.Errorf("%w: GTD expiration must be more than %v in the future", ErrOrderRejected, gtdSecurityThreshold)
+		}
+		expiration = big.NewInt(opts.Expiration.Unix())
+	case OrderTypeGTC, OrderTypeFOK, OrderTypeFAK:
+	default:
+		return nil, fmt.Errorf("%w: unsupported order type %q", ErrOrderRejected, orderType)
+	}
+
+	m, err := c.Market(ctx, marketID)
+	if err != nil {
+		return nil, err
+	}
+	// Trading UP or DOWN means trading that outcome's token
+	tokenID := m.TokenID(side)
 
-	salt := big.NewInt(time.Now().UnixNano())
-	nonce := big.NewInt(0) // TODO: Manage nonce properly (fetch from API or track locally)
-	expiration := big.NewInt(time.Now().Add(5 * time.Minute).Unix())
+	info, err := c.GetMarketInfo(ctx, marketID)
+	if err != nil {
+		return nil, err
+	}
+	if !info.AcceptingOrders {
+		// Refetched on the next order, in case the market was not open yet
+		c.forgetMarketInfo(marketID)
+		return nil, fmt.Errorf("%w: %s is not accepting orders", ErrMarketClosed, marketID)
+	}
+
+	maker, err := c.makerAddress()
+	if err != nil {
+		return nil, err
+	}
 
 	tokenIDBig, ok := new(big.Int).SetString(tokenID, 10)
 	if !ok {
@@ -119,169 +205,325 @@ func (c *PolymarketClient) PlaceOrder(tokenID string, side Side, size float64, p
 	}
 
 	// Amounts:
-	// For Limit Order (BUY):
-	// makerAmount = cost (USDC) = size * price
-	// takerAmount = return (Token) = size
-	// Scaling: USDC=6 decimals, CTF=6 decimals (usually)
-
-	// Convert to raw units (assuming 6 decimals for both for now)
-	// 1.0 = 1,000,000
-	rawPrice := price * 1e6
-	rawSize := size * 1e6
-	makerAmount := big.NewInt(int64(rawPrice * size)) // Cost = Price * Size
-	takerAmount := big.NewInt(int64(rawSize))         // Shares = Size
-
-	// 2. EIP-712 Signing
-	domain := apitypes.TypedDataDomain{
-		Name:              "Polymarket CTF Exchange",
-		Version:           "1",
-		ChainId:           math.NewHexOrDecimal256(c.ChainID),
-		VerifyingContract: "0x4bFb41d5B3570DeFd03C39a9A4D8dE6Bd8B8982E",
-	}
-
-	types := apitypes.Types{
-		"EIP712Domain": {
-			{Name: "name", Type: "string"},
-			{Name: "version", Type: "string"},
-			{Name: "chainId", Type: "uint256"},
-			{Name: "verifyingContract", Type: "address"},
-		},
-		"Order": {
-			{Name: "salt", Type: "uint256"},
-			{Name: "maker", Type: "address"},
-			{Name: "signer", Type: "address"},
-			{Name: "taker", Type: "address"},
-			{Name: "tokenId", Type: "uint256"},
-			{Name: "makerAmount", Type: "uint256"},
-			{Name: "takerAmount", Type: "uint256"},
-			{Name: "expiration", Type: "uint256"},
-			{Name: "nonce", Type: "uint256"},
-			{Name: "feeRateBps", Type: "uint256"},
-			{Name: "side", Type: "uint8"},
-			{Name: "signatureType", Type: "uint8"},
-		},
-	}
-
-	message := apitypes.TypedDataMessage{
-		"salt":          salt.String(),
-		"maker":         c.Funder.Hex(),
-		"signer":        c.Funder.Hex(),
-		"taker":         "0x0000000000000000000000000000000000000000",
-		"tokenId":       tokenIDBig.String(),
-		"makerAmount":   makerAmount.String(),
-		"takerAmount":   takerAmount.String(),
-		"expiration":    expiration.String(),
-		"nonce":         nonce.String(),
-		"feeRateBps":    "0",
-		"side":          fmt.Sprintf("%d", polymarketSide),
-		"signatureType": "0",
-	}
-
-	typedData := apitypes.TypedData{
-		Types:       types,
-		PrimaryType: "Order",
-		Domain:      domain,
-		Message:     message,
-	}
-
-	signature, err := c.signTypedData(typedData)
-	if err != nil {
-		return nil, fmt.Errorf("signing failed: %v", err)
+	// BUY:  makerAmount = cost (USDC) = size * price, takerAmount = shares = size
+	// SELL: makerAmount = shares = size, takerAmount = proceeds (USDC) = size * price
+	// Both USDC and CTF use 6 decimals, so Amount raw units go on-chain as is
+	makerAmt, takerAmt := orderAmounts(dir, orderType, price, size, info.TickSize)
+	shares, polySide := takerAmt, orderSideBuy
+	if dir == DirectionSell {
+		shares, polySide = makerAmt, orderSideSell
 	}
-
-	// 3. Construct API Payload
-	// We need to combine the signed fields + the signature
-	apiOrder := map[string]interface{}{
-		"salt":          salt.String(),
-		"maker":         c.Funder.Hex(),
-		"signer":        c.Funder.Hex(),
-		"taker":         "0x0000000000000000000000000000000000000000",
-		"tokenId":       tokenIDBig.String(),
-		"makerAmount":   makerAmount.String(),
-		"takerAmount":   takerAmount.String(),
-		"expiration":    expiration.String(),
-		"nonce":         nonce.String(),
-		"feeRateBps":    "0",
-		"side":          polymarketSide,
-		"signatureType": 0,
-		"signature":     hexutil.Encode(signature),
+	if shares < info.MinOrderSize {
+		return nil, fmt.Errorf("%w: order size %s below market minimum %s", ErrOrderRejected, shares, info.MinOrderSize)
+	}
+	if makerAmt <= 0 || takerAmt <= 0 {
+		return nil, fmt.Errorf("%w: order amounts round to zero (price %v, size %v)", ErrOrderRejected, price, size)
 	}
 
-	payload := map[string]interface{}{
-		"order":     apiOrder,
-		"owner":     c.Funder.Hex(),
-		"orderType": "GTC", // Good Til Cancelled
+	// 1. Prepare Order Data
+	salt, clientID, err := c.Orders.next(opts.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	o := &orderData{
+		Salt:          big.NewInt(salt),
+		Maker:         maker,
+		Signer:        c.signerAddress(),
+		TokenID:       tokenIDBig,
+		MakerAmount:   makerAmt.Raw(),
+		TakerAmount:   takerAmt.Raw(),
+		Expiration:    expiration,
+		Nonce:         c.Orders.Nonce(info.ExchangeAddress()),
+		FeeRateBps:    big.NewInt(0),
+		Side:          polySide,
+		SignatureType: c.SignatureType,
 	}
 
-	body, err := json.Marshal(payload)
+	// 2. EIP-712 Signing against the standard or neg-risk CTF Exchange
+	signed, err := c.signOrder(ctx, o, info.ExchangeAddress())
+	if err != nil {
+		c.Orders.release(salt)
+		return nil, err
+	}
+	// The CLOB identifies orders by their EIP-712 hash
+	hash, err := signer.TypedDataHash(c.orderTypedData(o, info.ExchangeAddress()))
 	if err != nil {
+		c.Orders.release(salt)
 		return nil, err
 	}
+	c.Orders.bind(salt, hexutil.Encode(hash))
+
+	// 3. Construct API Payload
+	payload := map[string]interface{}{
+		"order":     signed,
+		"owner":     c.APIKey,
+		"orderType": orderType,
+	}
 
-	// 4. Send POST Request
-	req, err := http.NewRequest("POST", c.BaseURL+"/order", bytes.NewBuffer(body))
+	// 4. Send POST Request (requires L2 headers)
+	resp, err := c.postOrder(ctx, payload, hexutil.Encode(hash))
+	if err == nil && (!resp.Success || resp.ErrorMsg != "") {
+		err = &APIError{StatusCode: http.StatusOK, Message: resp.ErrorMsg}
+	}
 	if err != nil {
+		if errors.Is(err, ErrInvalidTick) {
+			// The tick size changed since it was cached; let the next order refetch it
+			c.forgetMarketInfo(marketID)
+		}
+		// An order in unknown state may be on the book and keeps its identity
+		var unknown *OrderUnknownError
+		if !errors.As(err, &unknown) {
+			c.Orders.forget(hexutil.Encode(hash))
+		}
 		return nil, err
 	}
 
-	// Headers
-	req.Header.Set("Content-Type", "application/json")
-	// Note: Authentication headers (L1/L2) might be required depending on endpoint
-	// POST /order usually requires L1/L2 headers if using API Keys.
-	// But since we are signing the order with EOA key, maybe just the payload is enough?
-	// Docs say "This endpoint requires a L2 Header".
-	// L2 Header generation is complex (signing timestamp + method + path).
-	// For now, we'll try sending it. If it fails, the user needs to implement L2 auth.
-
-	// Implementation of L2 Auth Headers (simplified)
-	timestamp := fmt.Sprintf("%d", time.Now().UnixMilli())
-    _ = timestamp
-	// signMsg := fmt.Sprintf("%s%s%s", timestamp, "POST", "/order") // Simplified
-	// Real L2 auth involves signing this message with API Secret/Key.
-
-	// Assuming we are just preparing the code structure:
-	// c.addAuthHeaders(req)
-
-	fmt.Printf("Sending Order: %s\n", string(body))
-
-	// Uncomment to actually send
-	// resp, err := c.Client.Do(req)
-	// if err != nil { return nil, err }
-	// defer resp.Body.Close()
-	// ... handle response ...
-
-	return &Order{
-		ID:        "pending-tx", // would come from resp
-		MarketID:  tokenID,
+	order := &Order{
+		ID:        resp.OrderID,
+		ClientID:  clientID,
+		MarketID:  marketID,
 		Side:      side,
-		Price:     price,
+		Direction: dir,
+		Price:     limitPrice(price, info.TickSize).Float64(), // As signed
 		Size:      size,
-		Timestamp: time.Now(),
-	}, nil
+		Status:    parseOrderStatus(resp.Status, 0),
+		Type:      orderType,
+
+		MakerAmount: makerAmt,
+		TakerAmount: takerAmt,
+		Timestamp:   time.Now(),
+	}
+	if orderType == OrderTypeGTD {
+		order.Expiration = opts.Expiration
+	}
+	if resp.recovered != nil {
+		order.Status = resp.recovered.Status
+		order.SizeMatched = resp.recovered.SizeMatched
+	}
+
+	// For a BUY, making = USDC spent and taking = shares received; reversed for a SELL
+	making, errMaking := ParseAmount(resp.MakingAmount)
+	taking, errTaking := ParseAmount(resp.TakingAmount)
+	usdc, filled := making, taking
+	if dir == DirectionSell {
+		usdc, filled = taking, making
+	}
+	if errMaking == nil && errTaking == nil && filled > 0 {
+		order.SizeMatched = filled.Float64()
+		order.MatchedCost = usdc
+		order.AvgPrice = usdc.Quo(filled, RoundHalfUp).Float64() // Actual average fill price
+		if orderType == OrderTypeFAK && filled < shares {
+			// The unfilled remainder of a FAK order is killed
+			order.Status = OrderCancelled
+		}
+	}
+	if order.Status.IsFinal() {
+		c.Orders.forget(hexutil.Encode(hash))
+	}
+
+	return order, nil
 }
 
-func (c *PolymarketClient) signTypedData(typedData apitypes.TypedData) ([]byte, error) {
-	domainSeparator, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
-	if err != nil {
-		return nil, err
+// openOrder is the CLOB representation of an order in GET /data/order(s)
+type openOrder struct {
+	ID           string `json:"id"`
+	Status       string `json:"status"`
+	Market       string `json:"market"`
+	AssetID      string `json:"asset_id"`
+	Side         string `json:"side"`
+	OriginalSize string `json:"original_size"`
+	SizeMatched  string `json:"size_matched"`
+	Price        string `json:"price"`
+	CreatedAt    int64  `json:"created_at"`
+}
+
+func (c *PolymarketClient) toOrder(ctx context.Context, o *openOrder) *Order {
+	size, _ := strconv.ParseFloat(o.OriginalSize, 64)
+	matched, _ := strconv.ParseFloat(o.SizeMatched, 64)
+	price, _ := strconv.ParseFloat(o.Price, 64)
+
+	order := &Order{
+		ID:          o.ID,
+		MarketID:    o.Market,
+		Side:        SideUp,
+		Direction:   Direction(strings.ToUpper(o.Side)),
+		Price:       price,
+		Size:        size,
+		Status:      parseOrderStatus(o.Status, matched),
+		SizeMatched: matched,
+		Timestamp:   time.Unix(o.CreatedAt, 0),
 	}
-	typedDataHash, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
-	if err != nil {
+	if m, err := c.Market(ctx, o.Market); err == nil && m.TokenDown == o.AssetID {
+		order.Side = SideDown
+	}
+	order.ClientID, _ = c.Orders.ClientID(o.ID)
+	if order.Status.IsFinal() {
+		c.Orders.forget(o.ID)
+	}
+	return order
+}
+
+// GetOrder fetches the current state of an order (GET /data/order/{id})
+func (c *PolymarketClient) GetOrder(ctx context.Context, orderID string) (*Order, error) {
+	var o openOrder
+	if err := c.doL2(ctx, "GET", "/data/order/"+orderID, nil, &o); err != nil {
 		return nil, err
 	}
-	rawData := []byte(fmt.Sprintf("\x19\x01%s%s", string(domainSeparator), string(typedDataHash)))
-	hash := crypto.Keccak256(rawData)
+	if o.ID == "" {
+		return nil, &APIError{StatusCode: http.StatusNotFound, Message: "order " + orderID + " not found"}
+	}
+	return c.toOrder(ctx, &o), nil
+}
+
+// cancelResponse lists the orders the CLOB did and did not cancel
+type cancelResponse struct {
+	Canceled    []string          `json:"canceled"`
+	NotCanceled map[string]string `json:"not_canceled"`
+}
 
-	signature, err := crypto.Sign(hash, c.PrivateKey)
+// CancelOrder pulls a resting order from the book (DELETE /order)
+func (c *PolymarketClient) CancelOrder(ctx context.Context, orderID string) error {
+	var resp cancelResponse
+	if err := c.doL2(ctx, "DELETE", "/order", map[string]string{"orderID": orderID}, &resp); err != nil {
+		return err
+	}
+	if reason, ok := resp.NotCanceled[orderID]; ok {
+		return fmt.Errorf("order %s not cancelled: %s", orderID, reason)
+	}
+	return nil
+}
+
+// CancelAll pulls every open order of the account (DELETE /cancel-all)
+func (c *PolymarketClient) CancelAll(ctx context.Context) error {
+	var resp cancelResponse
+	if err := c.doL2(ctx, "DELETE", "/cancel-all", nil, &resp); err != nil {
+		return err
+	}
+	if len(resp.NotCanceled) > 0 {
+		return fmt.Errorf("%d orders not cancelled", len(resp.NotCanceled))
+	}
+	return nil
+}
+
+// endCursor marks the last page of a paginated CLOB response
+const endCursor = "LTE="
+
+// OpenOrders lists the resting orders in a market (GET /data/orders), following pagination
+func (c *PolymarketClient) OpenOrders(ctx context.Context, marketID string) ([]*Order, error) {
+	var orders []*Order
+	cursor := ""
+	for cursor != endCursor {
+		path := "/data/orders?market=" + url.QueryEscape(marketID)
+		if cursor != "" {
+			path += "&next_cursor=" + url.QueryEscape(cursor)
+		}
+
+		var page struct {
+			Data       []openOrder `json:"data"`
+			NextCursor string      `json:"next_cursor"`
+		}
+		if err := c.doL2(ctx, "GET", path, nil, &page); err != nil {
+			return nil, err
+		}
+		for i := range page.Data {
+			orders = append(orders, c.toOrder(ctx, &page.Data[i]))
+		}
+
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	return orders, nil
+}
+
+// orderResponse is the CLOB reply to POST /order
+type orderResponse struct {
+	Success            bool     `json:"success"`
+	ErrorMsg           string   `json:"errorMsg"`
+	OrderID            string   `json:"orderID"`
+	Status             string   `json:"status"`
+	MakingAmount       string   `json:"makingAmount"`
+	TakingAmount       string   `json:"takingAmount"`
+	TransactionsHashes []string `json:"transactionsHashes"`
+
+	recovered *Order // Set when the order was looked up after a failed post
+}
+
+// postOrder submits a signed order without ever placing it twice. After an ambiguous
+// failure (timeout, 5xx) the order is looked up by its hash and only resubmitted, with
+// the identical signature, when the CLOB does not know it. When its fate cannot be
+// settled the error is an *OrderUnknownError carrying the hash.
+func (c *PolymarketClient) postOrder(ctx context.Context, payload map[string]interface{}, orderID string) (*orderResponse, error) {
+	for attempt := 0; ; attempt++ {
+		var resp orderResponse
+		err := c.doL2(ctx, "POST", "/order", payload, &resp)
+		if isDuplicated(err, &resp) {
+			// An earlier attempt reached the book after all
+			recovered, getErr := c.recoverOrder(ctx, orderID)
+			if getErr != nil {
+				return nil, &OrderUnknownError{ID: orderID, Err: getErr}
+			}
+			return recovered, nil
+		}
+		if err == nil || !ambiguous(err) {
+			return &resp, err
+		}
+		if attempt >= c.Retry.MaxRetries {
+			return nil, &OrderUnknownError{ID: orderID, Err: err}
+		}
+
+		if sleepErr := sleep(ctx, c.Retry.backoff(attempt)); sleepErr != nil {
+			return nil, &OrderUnknownError{ID: orderID, Err: fmt.Errorf("%v, then %w", err, sleepErr)}
+		}
+		recovered, getErr := c.recoverOrder(ctx, orderID)
+		if getErr == nil {
+			return recovered, nil
+		}
+		if !isNotFound(getErr) {
+			return nil, &OrderUnknownError{ID: orderID, Err: fmt.Errorf("%v, then %w", err, getErr)}
+		}
+	}
+}
+
+// recoverOrder reports an order that went through although its post failed. Only
+// its state is known: the fill amounts are left to later lookups.
+func (c *PolymarketClient) recoverOrder(ctx context.Context, orderID string) (*orderResponse, error) {
+	o, err := c.GetOrder(ctx, orderID)
 	if err != nil {
 		return nil, err
 	}
+	return &orderResponse{Success: true, OrderID: o.ID, Status: string(o.Status), recovered: o}, nil
+}
+
+// doL2 sends an authenticated JSON request and decodes the response into out
+func (c *PolymarketClient) doL2(ctx context.Context, method, path string, payload interface{}, out interface{}) error {
+	var body []byte
+	if payload != nil {
+		var err error
+		body, err = json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+	}
 
-	if signature[64] < 27 {
-		signature[64] += 27
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// The signature covers the path only, not the query string
+	requestPath, _, _ := strings.Cut(path, "?")
+	if err := c.addL2Headers(req, requestPath, body); err != nil {
+		return err
 	}
 
-	return signature, nil
+	return c.do(req, out)
+}
+
+// signTypedData signs an EIP-712 message with the client's signer
+func (c *PolymarketClient) signTypedData(ctx context.Context, typedData apitypes.TypedData) ([]byte, error) {
+	return c.Signer.SignTypedData(ctx, typedData)
 }
 
 func (c *PolymarketClient) CurrentTime() time.Time {
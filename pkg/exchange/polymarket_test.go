@@ -0,0 +1,402 @@
+package exchange
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+
+	"poly/pkg/signer"
+)
+
+var testSecret = base64.URLEncoding.EncodeToString([]byte("super-secret-key"))
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *PolymarketClient {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	funder := crypto.PubkeyToAddress(key.PublicKey)
+
+	c, err := NewPolymarketClient("test-key", testSecret, "test-pass", hexutil.Encode(crypto.FromECDSA(key)), funder.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.BaseURL = srv.URL
+	c.RegisterMarket(Market{ID: "0xcond", TokenUp: "111", TokenDown: "222"})
+	c.infos["0xcond"] = &MarketInfo{
+		MarketID:        "0xcond",
+		TickSize:        AmountFromFloat(0.01),
+		MinOrderSize:    AmountFromFloat(5),
+		AcceptingOrders: true,
+		fetchedAt:       time.Now(),
+	}
+	return c
+}
+
+// decodeBody decodes the JSON body of a request to a test server. Handlers run outside
+// the test goroutine, where t.Fatal must not be called: failures are reported with
+// t.Errorf and a 400 reply, and the handler returns when decodeBody reports false.
+func decodeBody(t *testing.T, w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	t.Helper()
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		t.Errorf("decoding %s %s: %v", r.Method, r.URL.Path, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func TestPlaceOrderSendsL2Headers(t *testing.T) {
+	ctx := context.Background()
+	var c *PolymarketClient
+	c = newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/order" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		want, _ := buildHMACSignature(testSecret, r.Header.Get(headerTimestamp), "POST", "/order", body)
+		if got := r.Header.Get(headerSignature); got != want {
+			t.Errorf("bad signature: got %s want %s", got, want)
+		}
+		if r.Header.Get(headerAPIKey) != "test-key" || r.Header.Get(headerPassphrase) != "test-pass" {
+			t.Errorf("missing credentials in headers: %v", r.Header)
+		}
+		if r.Header.Get(headerAddress) != c.signerAddress().Hex() {
+			t.Errorf("unexpected address header %s", r.Header.Get(headerAddress))
+		}
+
+		var payload struct {
+			Owner     string                 `json:"owner"`
+			OrderType string                 `json:"orderType"`
+			Order     map[string]interface{} `json:"order"`
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Errorf("decoding order: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if payload.Owner != "test-key" {
+			t.Errorf("owner should be the api key, got %s", payload.Owner)
+		}
+		if payload.Order["signature"] == "" {
+			t.Error("order is not signed")
+		}
+
+		w.Write([]byte(`{"success":true,"errorMsg":"","orderID":"0xabc","status":"matched","makingAmount":"4.5","takingAmount":"10"}`))
+	})
+
+	// The price is signed at the nearest tick
+	order, err := c.PlaceOrder(ctx, "0xcond", SideUp, DirectionBuy, 10, 0.4512, OrderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.ID != "0xabc" || order.Status != OrderMatched || order.Price != 0.45 {
+		t.Errorf("unexpected order %+v", order)
+	}
+	if order.SizeMatched != 10 || order.AvgPrice != 0.45 {
+		t.Errorf("unexpected fill: size %v price %v", order.SizeMatched, order.AvgPrice)
+	}
+}
+
+func TestPlaceOrderRejected(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"not enough balance / allowance"}`))
+	})
+
+	_, err := c.PlaceOrder(ctx, "0xcond", SideUp, DirectionBuy, 10, 0.45, OrderOptions{})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *APIError, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest || apiErr.Message != "not enough balance / allowance" {
+		t.Errorf("unexpected error %+v", apiErr)
+	}
+	if !errors.Is(err, ErrInsufficientBalance) {
+		t.Errorf("expected ErrInsufficientBalance, got %v", err)
+	}
+}
+
+func TestPlaceOrderClosedMarket(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	})
+	c.infos["0xcond"].AcceptingOrders = false
+
+	if _, err := c.PlaceOrder(ctx, "0xcond", SideUp, DirectionBuy, 10, 0.45, OrderOptions{}); !errors.Is(err, ErrMarketClosed) {
+		t.Errorf("expected ErrMarketClosed, got %v", err)
+	}
+	if _, ok := c.infos["0xcond"]; ok {
+		t.Error("expected the closed market's info to be refetched next time")
+	}
+}
+
+// offlineSigner stands in for a remote signer that may be unreachable
+type offlineSigner struct {
+	signer.Signer
+	calls int
+	err   error
+}
+
+func (o *offlineSigner) SignTypedData(ctx context.Context, typedData apitypes.TypedData) ([]byte, error) {
+	o.calls++
+	if o.err != nil {
+		return nil, o.err
+	}
+	return o.Signer.SignTypedData(ctx, typedData)
+}
+
+func TestPlaceOrderWithSigner(t *testing.T) {
+	ctx := context.Background()
+	key, _ := crypto.GenerateKey()
+	s := &offlineSigner{Signer: signer.NewKeySigner(key)}
+
+	posted := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		posted++
+		var payload struct {
+			Order map[string]interface{} `json:"order"`
+		}
+		if !decodeBody(t, w, r, &payload) {
+			return
+		}
+		if payload.Order["signer"] != s.Address().Hex() {
+			t.Errorf("order signer = %v, want %s", payload.Order["signer"], s.Address().Hex())
+		}
+		w.Write([]byte(`{"success":true,"orderID":"0xabc","status":"live"}`))
+	})
+	c.Signer = s
+	c.Funder = s.Address()
+
+	if _, err := c.PlaceOrder(ctx, "0xcond", SideUp, DirectionBuy, 10, 0.45, OrderOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if s.calls != 1 || posted != 1 {
+		t.Errorf("expected one signature and one post, got %d and %d", s.calls, posted)
+	}
+
+	// Nothing is posted when the signer is down
+	s.err = errors.New("connection refused")
+	if _, err := c.PlaceOrder(ctx, "0xcond", SideUp, DirectionBuy, 10, 0.45, OrderOptions{}); err == nil {
+		t.Error("expected the signing failure to be returned")
+	}
+	if posted != 1 {
+		t.Errorf("expected no post without a signature, got %d", posted)
+	}
+}
+
+func TestGetTickerFetchesBothBooks(t *testing.T) {
+	ctx := context.Background()
+	books := map[string]string{
+		"111": `{"asks":[{"price":"0.52","size":"100"},{"price":"0.48","size":"50"}],"bids":[{"price":"0.46","size":"10"}]}`,
+		"222": `{"asks":[{"price":"0.55","size":"20"}],"bids":[]}`,
+	}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		book, ok := books[r.URL.Query().Get("token_id")]
+		if r.URL.Path != "/book" || !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(book))
+	})
+
+	ticker, err := c.GetTicker(ctx, "0xcond")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticker.PriceUp != 0.48 || ticker.PriceDown != 0.55 {
+		t.Errorf("unexpected prices: up %v down %v", ticker.PriceUp, ticker.PriceDown)
+	}
+	if ticker.MarketID != "0xcond" || ticker.Timestamp.IsZero() {
+		t.Errorf("unexpected ticker %+v", ticker)
+	}
+
+	if _, err := c.GetTicker(ctx, "0xunknown"); err == nil {
+		t.Error("expected error for unregistered market")
+	}
+}
+
+func TestPlaceOrderRoutesTokenBySide(t *testing.T) {
+	ctx := context.Background()
+	var tokenIDs []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/order" {
+			http.NotFound(w, r)
+			return
+		}
+		var payload struct {
+			Order struct {
+				TokenID string `json:"tokenId"`
+			} `json:"order"`
+		}
+		if !decodeBody(t, w, r, &payload) {
+			return
+		}
+		tokenIDs = append(tokenIDs, payload.Order.TokenID)
+		w.Write([]byte(`{"success":true,"orderID":"0x1","status":"live"}`))
+	})
+
+	if _, err := c.PlaceOrder(ctx, "0xcond", SideUp, DirectionBuy, 10, 0.4, OrderOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.PlaceOrder(ctx, "0xcond", SideDown, DirectionBuy, 10, 0.5, OrderOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tokenIDs) != 2 || tokenIDs[0] != "111" || tokenIDs[1] != "222" {
+		t.Errorf("expected UP then DOWN token ids [111 222], got %v", tokenIDs)
+	}
+
+	if _, err := c.PlaceOrder(ctx, "0xunknown", SideUp, DirectionBuy, 10, 0.4, OrderOptions{}); err == nil {
+		t.Error("expected error for unregistered market")
+	}
+}
+
+func TestOrderLifecycle(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		// Query strings are not part of the signed path
+		want, _ := buildHMACSignature(testSecret, r.Header.Get(headerTimestamp), r.Method, r.URL.Path, nil)
+		if r.Method == "GET" && r.Header.Get(headerSignature) != want {
+			t.Errorf("bad signature for %s", r.URL)
+		}
+
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/data/order/0x1":
+			w.Write([]byte(`{"id":"0x1","status":"LIVE","market":"0xcond","asset_id":"222","side":"BUY","original_size":"10","size_matched":"4","price":"0.5"}`))
+		case r.Method == "DELETE" && r.URL.Path == "/order":
+			w.Write([]byte(`{"canceled":[],"not_canceled":{"0x1":"order already matched"}}`))
+		case r.Method == "DELETE" && r.URL.Path == "/cancel-all":
+			w.Write([]byte(`{"canceled":["0x1","0x2"],"not_canceled":{}}`))
+		case r.Method == "GET" && r.URL.Path == "/data/orders":
+			if r.URL.Query().Get("next_cursor") == "" {
+				w.Write([]byte(`{"data":[{"id":"0x1","status":"LIVE","market":"0xcond","asset_id":"111","original_size":"10","size_matched":"0","price":"0.4"}],"next_cursor":"MQ=="}`))
+			} else {
+				w.Write([]byte(`{"data":[{"id":"0x2","status":"LIVE","market":"0xcond","asset_id":"222","original_size":"5","size_matched":"0","price":"0.6"}],"next_cursor":"LTE="}`))
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	order, err := c.GetOrder(ctx, "0x1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != OrderPartiallyFilled || order.SizeMatched != 4 || order.Side != SideDown {
+		t.Errorf("unexpected order %+v", order)
+	}
+
+	if err := c.CancelOrder(ctx, "0x1"); err == nil {
+		t.Error("expected error when the order was not cancelled")
+	}
+	if err := c.CancelAll(ctx); err != nil {
+		t.Error(err)
+	}
+
+	orders, err := c.OpenOrders(ctx, "0xcond")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 2 || orders[0].Side != SideUp || orders[1].Side != SideDown {
+		t.Errorf("unexpected open orders %+v", orders)
+	}
+}
+
+func TestPlaceOrderTypes(t *testing.T) {
+	ctx := context.Background()
+	var payload struct {
+		OrderType string `json:"orderType"`
+		Order     struct {
+			Expiration  string `json:"expiration"`
+			MakerAmount string `json:"makerAmount"`
+			TakerAmount string `json:"takerAmount"`
+		} `json:"order"`
+	}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if !decodeBody(t, w, r, &payload) {
+			return
+		}
+		w.Write([]byte(`{"success":true,"orderID":"0x1","status":"live"}`))
+	})
+
+	// GTC orders are signed without expiration
+	if _, err := c.PlaceOrder(ctx, "0xcond", SideUp, DirectionBuy, 10, 0.45, OrderOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if payload.OrderType != "GTC" || payload.Order.Expiration != "0" {
+		t.Errorf("unexpected GTC payload %+v", payload)
+	}
+
+	expiry := time.Now().Add(10 * time.Minute)
+	order, err := c.PlaceOrder(ctx, "0xcond", SideUp, DirectionBuy, 10, 0.45, OrderOptions{Type: OrderTypeGTD, Expiration: expiry})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload.OrderType != "GTD" || payload.Order.Expiration != strconv.FormatInt(expiry.Unix(), 10) {
+		t.Errorf("unexpected GTD payload %+v", payload)
+	}
+	if !order.Expiration.Equal(expiry) {
+		t.Errorf("order should carry its expiration")
+	}
+
+	// Market orders: USDC side truncated to cents, shares to 4 decimals
+	if _, err := c.PlaceOrder(ctx, "0xcond", SideUp, DirectionBuy, 10.07, 0.33, OrderOptions{Type: OrderTypeFOK}); err != nil {
+		t.Fatal(err)
+	}
+	if payload.OrderType != "FOK" || payload.Order.MakerAmount != "3320000" || payload.Order.TakerAmount != "10060600" {
+		t.Errorf("unexpected FOK payload %+v", payload)
+	}
+
+	if _, err := c.PlaceOrder(ctx, "0xcond", SideUp, DirectionBuy, 10, 0.45, OrderOptions{Type: OrderTypeGTD, Expiration: time.Now().Add(30 * time.Second)}); err == nil {
+		t.Error("expected error for GTD expiration inside the security threshold")
+	}
+}
+
+func TestPlaceSellOrder(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Order signedOrder `json:"order"`
+		}
+		if !decodeBody(t, w, r, &payload) {
+			return
+		}
+		o := payload.Order
+		if o.Side != "SELL" || o.TokenID != "222" || o.MakerAmount != "10000000" || o.TakerAmount != "6000000" {
+			t.Errorf("unexpected sell order %+v", o)
+		}
+		// Selling 10 shares, received 6.2 USDC
+		w.Write([]byte(`{"success":true,"orderID":"0x2","status":"matched","makingAmount":"10","takingAmount":"6.2"}`))
+	})
+
+	order, err := c.PlaceOrder(ctx, "0xcond", SideDown, DirectionSell, 10, 0.60, OrderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Direction != DirectionSell || order.SizeMatched != 10 || order.AvgPrice != 0.62 {
+		t.Errorf("unexpected order %+v", order)
+	}
+	if order.Cost().String() != "6.2" {
+		t.Errorf("expected proceeds of 6.2, got %s", order.Cost())
+	}
+}
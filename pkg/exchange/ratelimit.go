@@ -0,0 +1,120 @@
+package exchange
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// endpointClass groups the CLOB endpoints sharing a rate limit
+type endpointClass int
+
+const (
+	classGeneral endpointClass = iota
+	classBook
+	classMarkets
+	classPostOrder
+	classCancel
+	classCancelAll
+	classData
+	classAuth
+	classBalance
+)
+
+// endpointLimits follows the published CLOB limits (requests per 10s window),
+// kept slightly below them so bursts from other tools on the same IP still fit
+var endpointLimits = map[endpointClass]struct {
+	per10s float64
+	burst  int
+}{
+	classGeneral:   {8000, 100},
+	classBook:      {1400, 50},
+	classMarkets:   {200, 20},
+	classPostOrder: {3000, 50}, // 3500/10s burst, 36000/10min sustained
+	classCancel:    {2800, 50},
+	classCancelAll: {200, 5},
+	classData:      {450, 20},
+	classAuth:      {90, 5},
+	classBalance:   {180, 10},
+}
+
+// classify maps a request to its endpoint class
+func classify(method, path string) endpointClass {
+	switch {
+	case path == "/book" || path == "/books":
+		return classBook
+	case strings.HasPrefix(path, "/markets"):
+		return classMarkets
+	case path == "/order" && method == "POST":
+		return classPostOrder
+	case path == "/order" && method == "DELETE", path == "/orders" && method == "DELETE":
+		return classCancel
+	case path == "/cancel-all":
+		return classCancelAll
+	case strings.HasPrefix(path, "/data/"):
+		return classData
+	case strings.HasPrefix(path, "/auth/"):
+		return classAuth
+	case strings.HasPrefix(path, "/balance-allowance"):
+		return classBalance
+	}
+	return classGeneral
+}
+
+// RateLimiter throttles requests with one token bucket per endpoint class. The CLOB
+// limits are per account and IP, so clients share DefaultRateLimiter by default.
+type RateLimiter struct {
+	buckets map[endpointClass]*tokenBucket // Fixed at creation
+}
+
+// DefaultRateLimiter is shared by every client created with NewPolymarketClient
+var DefaultRateLimiter = NewRateLimiter()
+
+func NewRateLimiter() *RateLimiter {
+	r := &RateLimiter{buckets: make(map[endpointClass]*tokenBucket)}
+	for class, l := range endpointLimits {
+		r.buckets[class] = newTokenBucket(l.per10s/10, l.burst)
+	}
+	return r
+}
+
+// Wait blocks until a request to the endpoint may be sent or ctx is done
+func (r *RateLimiter) Wait(ctx context.Context, method, path string) error {
+	b := r.buckets[classify(method, path)]
+	return sleep(ctx, b.reserve(time.Now()))
+}
+
+// tokenBucket refills rate tokens per second up to burst
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst)}
+}
+
+// reserve takes a token, returning how long to wait before it may be used.
+// Tokens go negative while callers wait, which queues them in order.
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
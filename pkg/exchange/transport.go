@@ -0,0 +1,170 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how failed requests are retried
+type RetryPolicy struct {
+	MaxRetries int
+	BaseDelay  time.Duration // Delay before the first retry, doubled for each further one
+	MaxDelay   time.Duration
+}
+
+// DefaultRetryPolicy retries for up to about 3 seconds
+var DefaultRetryPolicy = RetryPolicy{MaxRetries: 4, BaseDelay: 200 * time.Millisecond, MaxDelay: 2 * time.Second}
+
+// backoff returns the jittered delay before retry number attempt (0-based)
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay << uint(attempt)
+	if d > p.MaxDelay || d <= 0 {
+		d = p.MaxDelay
+	}
+	// Equal jitter: keep half, randomize the rest so clients don't retry in lockstep
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date
+func retryAfter(resp *http.Response) time.Duration {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+// retryableStatus reports whether a reply means "try again later"
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// notSent reports whether a transport error happened before the request reached the server
+func notSent(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// ambiguous reports whether a failed request may nonetheless have been processed
+func ambiguous(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500
+	}
+	return !notSent(err)
+}
+
+// do executes the request within its context's deadline, turning non-2xx replies into *APIError. Requests are rate
+// limited, and retried with backoff on 429, 5xx and transport errors. POSTs are not
+// idempotent: they are only retried when the server provably did not process them
+// (429 or a failed dial); see postOrder for how orders recover from the rest.
+func (c *PolymarketClient) do(req *http.Request, out interface{}) error {
+	idempotent := req.Method != "POST"
+
+	for attempt := 0; ; attempt++ {
+		if c.Limiter != nil {
+			if err := c.Limiter.Wait(req.Context(), req.Method, req.URL.Path); err != nil {
+				return err
+			}
+		}
+
+		// Every attempt needs a fresh body
+		try := req
+		if attempt > 0 {
+			try = req.Clone(req.Context())
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return err
+				}
+				try.Body = body
+			}
+		}
+
+		var wait time.Duration
+		err := c.send(try, out, &wait)
+		if err == nil {
+			return nil
+		}
+
+		retry := false
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			retry = retryableStatus(apiErr.StatusCode) &&
+				(idempotent || apiErr.StatusCode == http.StatusTooManyRequests)
+		} else {
+			retry = idempotent || notSent(err)
+		}
+		if !retry || attempt >= c.Retry.MaxRetries || req.Context().Err() != nil {
+			return err
+		}
+
+		if d := c.Retry.backoff(attempt); d > wait {
+			wait = d
+		}
+		if sleep(req.Context(), wait) != nil {
+			return err
+		}
+	}
+}
+
+// transportError marks a failed round trip as a network error, unless it failed
+// because the caller gave up
+func transportError(req *http.Request, err error) error {
+	if req.Context().Err() != nil {
+		return err
+	}
+	return &NetworkError{Err: err}
+}
+
+// sleep waits for d, returning early with ctx's error once it is done
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// send performs a single attempt, reporting the server's Retry-After in wait
+func (c *PolymarketClient) send(req *http.Request, out interface{}, wait *time.Duration) error {
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return transportError(req, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		*wait = retryAfter(resp)
+		return newAPIError(resp)
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
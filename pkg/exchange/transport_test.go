@@ -0,0 +1,278 @@
+package exchange
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+var fastRetry = RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+func TestRetriesTransientFailures(t *testing.T) {
+	ctx := context.Background()
+	calls := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch calls {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte(`{"asks": [{"price": "0.5", "size": "10"}]}`))
+		}
+	})
+	c.Retry = fastRetry
+
+	ob, err := c.getOrderBook(ctx, "111")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ask, _ := ob.BestAsk(); ask != 0.5 || calls != 3 {
+		t.Errorf("got ask %v after %d calls", ask, calls)
+	}
+
+	// Persistent failures give up after MaxRetries
+	calls = 0
+	c = newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	c.Retry = fastRetry
+
+	_, err = c.getOrderBook(ctx, "111")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected the last 503, got %v", err)
+	}
+	if calls != 1+fastRetry.MaxRetries {
+		t.Errorf("expected %d attempts, got %d", 1+fastRetry.MaxRetries, calls)
+	}
+}
+
+func TestRequestsStopAtDeadline(t *testing.T) {
+	calls := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	c.Retry = RetryPolicy{MaxRetries: 10, BaseDelay: time.Second, MaxDelay: time.Second}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := c.getOrderBook(ctx, "111")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Errorf("retries outlived the deadline: %v", d)
+	}
+	if calls != 1 {
+		t.Errorf("expected no retry after the deadline, got %d calls", calls)
+	}
+
+	// A cancelled context fails fast without reaching the server
+	calls = 0
+	cancel()
+	if _, err := c.getOrderBook(ctx, "111"); !errors.Is(err, context.DeadlineExceeded) || calls != 0 {
+		t.Errorf("got %v after %d calls", err, calls)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	resp := &http.Response{Header: http.Header{}}
+	resp.Header.Set("Retry-After", "3")
+	if d := retryAfter(resp); d != 3*time.Second {
+		t.Errorf("Retry-After seconds = %v", d)
+	}
+	resp.Header.Set("Retry-After", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	if d := retryAfter(resp); d < 58*time.Second || d > time.Minute {
+		t.Errorf("Retry-After date = %v", d)
+	}
+}
+
+func TestOrderPostIsNeverDoubleSubmitted(t *testing.T) {
+	ctx := context.Background()
+	var posts [][]byte
+	placed := false
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/order":
+			body, _ := io.ReadAll(r.Body)
+			posts = append(posts, body)
+			// The order is accepted, but the reply is lost
+			placed = true
+			w.WriteHeader(http.StatusBadGateway)
+		case r.Method == "GET" && len(r.URL.Path) > len("/data/order/"):
+			if !placed {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(`{"id": "` + r.URL.Path[len("/data/order/"):] + `", "status": "LIVE",
+				"market": "0xcond", "asset_id": "111", "side": "BUY",
+				"original_size": "10", "size_matched": "0", "price": "0.5"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	c.Retry = fastRetry
+
+	order, err := c.PlaceOrder(ctx, "0xcond", SideUp, DirectionBuy, 10, 0.5, OrderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 1 {
+		t.Errorf("order was posted %d times", len(posts))
+	}
+	if len(order.ID) != 66 || order.Status != OrderLive {
+		t.Errorf("expected the recovered order, got %+v", order)
+	}
+}
+
+func TestOrderPostInUnknownState(t *testing.T) {
+	ctx := context.Background()
+	posts := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			posts++
+		}
+		// Neither the post nor the lookups get an answer
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	c.Retry = fastRetry
+
+	_, err := c.PlaceOrder(ctx, "0xcond", SideUp, DirectionBuy, 10, 0.5, OrderOptions{})
+	var unknown *OrderUnknownError
+	if !errors.As(err, &unknown) || len(unknown.ID) != 66 {
+		t.Fatalf("expected an OrderUnknownError carrying the order hash, got %v", err)
+	}
+	if !errors.Is(err, ErrNetwork) {
+		t.Errorf("expected the unknown state to count as a network error")
+	}
+	if posts != 1 {
+		t.Errorf("order was posted %d times", posts)
+	}
+}
+
+func TestOrderPostDuplicatedIsRecovered(t *testing.T) {
+	ctx := context.Background()
+	posts := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/order":
+			posts++
+			if posts == 1 {
+				w.WriteHeader(http.StatusGatewayTimeout)
+				return
+			}
+			// The first post made it after all
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": "INVALID_ORDER_DUPLICATED: order is invalid. Duplicated."}`))
+		case r.Method == "GET" && posts < 2:
+			w.WriteHeader(http.StatusNotFound) // Not indexed yet
+		case r.Method == "GET":
+			w.Write([]byte(`{"id": "` + r.URL.Path[len("/data/order/"):] + `", "status": "MATCHED",
+				"market": "0xcond", "asset_id": "111", "side": "BUY",
+				"original_size": "10", "size_matched": "10", "price": "0.5"}`))
+		}
+	})
+	c.Retry = fastRetry
+
+	order, err := c.PlaceOrder(ctx, "0xcond", SideUp, DirectionBuy, 10, 0.5, OrderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != OrderMatched || order.SizeMatched != 10 {
+		t.Errorf("expected the filled order, got %+v", order)
+	}
+	// The exact fill value is unknown: not made up from the limit price
+	if order.MatchedCost != 0 || order.AvgPrice != 0 {
+		t.Errorf("expected no invented fill amounts, got %+v", order)
+	}
+}
+
+func TestOrderPostRetries(t *testing.T) {
+	ctx := context.Background()
+	var posts [][]byte
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/order":
+			body, _ := io.ReadAll(r.Body)
+			posts = append(posts, body)
+			switch len(posts) {
+			case 1:
+				w.WriteHeader(http.StatusTooManyRequests) // Rejected before processing
+			case 2:
+				w.WriteHeader(http.StatusGatewayTimeout) // Lost on the way
+			default:
+				w.Write([]byte(`{"success": true, "orderID": "0xabc", "status": "live"}`))
+			}
+		case r.Method == "GET":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	c.Retry = fastRetry
+
+	order, err := c.PlaceOrder(ctx, "0xcond", SideUp, DirectionBuy, 10, 0.5, OrderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.ID != "0xabc" || len(posts) != 3 {
+		t.Errorf("got order %s after %d posts", order.ID, len(posts))
+	}
+
+	// Resubmissions carry the identical signed order, so the CLOB can dedupe them
+	for i := 1; i < len(posts); i++ {
+		if !bytes.Equal(posts[i], posts[0]) {
+			t.Errorf("post %d differs from the first:\n%s\n%s", i, posts[i], posts[0])
+		}
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	b := newTokenBucket(10, 2) // 10/s, burst 2
+	now := time.Now()
+
+	if b.reserve(now) != 0 || b.reserve(now) != 0 {
+		t.Error("burst should pass immediately")
+	}
+	if d := b.reserve(now); d != 100*time.Millisecond {
+		t.Errorf("third request should wait 100ms, got %v", d)
+	}
+	if d := b.reserve(now); d != 200*time.Millisecond {
+		t.Errorf("queued requests should wait in turn, got %v", d)
+	}
+	if d := b.reserve(now.Add(time.Second)); d != 0 {
+		t.Errorf("bucket should have refilled, got %v", d)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	cases := []struct {
+		method, path string
+		want         endpointClass
+	}{
+		{"GET", "/book", classBook},
+		{"POST", "/order", classPostOrder},
+		{"DELETE", "/order", classCancel},
+		{"DELETE", "/cancel-all", classCancelAll},
+		{"GET", "/data/orders", classData},
+		{"GET", "/markets/0xcond", classMarkets},
+		{"GET", "/auth/derive-api-key", classAuth},
+		{"GET", "/balance-allowance", classBalance},
+		{"GET", "/time", classGeneral},
+	}
+	for _, tc := range cases {
+		if got := classify(tc.method, tc.path); got != tc.want {
+			t.Errorf("classify(%s %s) = %d, want %d", tc.method, tc.path, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package exchange
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Factories deploying the funder wallets of Polymarket accounts on Polygon
+const (
+	ProxyFactoryAddress = "0xaB45c5A4B0c941a2F231C04C3f49182e1A254052"
+	SafeFactoryAddress  = "0xaacFeEa03eb1561C4e67d661e40682Bd20E3541b"
+)
+
+// Hashes of the wallet creation code, which fix the CREATE2 addresses
+var (
+	proxyInitCodeHash = common.HexToHash("0xd21df8dc65880a8606f09fe0ce3df9b8869287ab0b058be05aa9e8af6330a00b")
+	safeInitCodeHash  = common.HexToHash("0x2bce2127ff07fb632d16c8347c4ebf501f4841168bed00d9e6ef715ddb6fcecf")
+)
+
+// ProxyWalletAddress returns the Polymarket proxy wallet of an EOA, whether deployed
+// yet or not. The factory salts it with the packed owner address.
+func ProxyWalletAddress(owner common.Address) common.Address {
+	salt := crypto.Keccak256Hash(owner.Bytes())
+	return crypto.CreateAddress2(common.HexToAddress(ProxyFactoryAddress), salt, proxyInitCodeHash.Bytes())
+}
+
+// SafeAddress returns the Gnosis Safe Polymarket creates for an EOA. The factory
+// salts it with the ABI-encoded owner address.
+func SafeAddress(owner common.Address) common.Address {
+	salt := crypto.Keccak256Hash(common.LeftPadBytes(owner.Bytes(), 32))
+	return crypto.CreateAddress2(common.HexToAddress(SafeFactoryAddress), salt, safeInitCodeHash.Bytes())
+}
+
+// FunderAddress returns the address holding the funds of a signer for a signature type
+func FunderAddress(signer common.Address, sigType SignatureType) (common.Address, error) {
+	switch sigType {
+	case SignatureEOA:
+		return signer, nil
+	case SignaturePolyProxy:
+		return ProxyWalletAddress(signer), nil
+	case SignatureGnosisSafe:
+		return SafeAddress(signer), nil
+	}
+	return common.Address{}, fmt.Errorf("unsupported signature type %d", sigType)
+}
+
+// DeriveFunder returns the funder of the client's signer for its signature type,
+// sparing users to look up their proxy or Safe address
+func (c *PolymarketClient) DeriveFunder() (common.Address, error) {
+	return FunderAddress(c.signerAddress(), c.SignatureType)
+}
+
+// VerifyFunder checks that the configured funder is the wallet the signer controls
+// with the signature type; orders from another funder would be rejected
+func (c *PolymarketClient) VerifyFunder() error {
+	want, err := c.DeriveFunder()
+	if err != nil {
+		return err
+	}
+	if c.Funder != want {
+		return fmt.Errorf("funder %s is not the %s wallet of %s, expected %s",
+			c.Funder.Hex(), c.SignatureType, c.signerAddress().Hex(), want.Hex())
+	}
+	return nil
+}
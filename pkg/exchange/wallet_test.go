@@ -0,0 +1,55 @@
+package exchange
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestFunderAddress(t *testing.T) {
+	// Pinned wallets of a fixed owner, so a change to a factory, an init code hash or
+	// a salt encoding shows up as a different address rather than passing unnoticed
+	eoa := common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266")
+	for sigType, want := range map[SignatureType]common.Address{
+		SignatureEOA:        eoa,
+		SignaturePolyProxy:  common.HexToAddress("0x365f0CA36Ae1f641E02fE3B7743673da42A13A70"),
+		SignatureGnosisSafe: common.HexToAddress("0xd93B25cb943D14d0d34FBaF01Fc93a0f8b5F6E47"),
+	} {
+		got, err := FunderAddress(eoa, sigType)
+		if err != nil || got != want {
+			t.Errorf("%s funder = %s (err %v), want %s", sigType, got.Hex(), err, want.Hex())
+		}
+	}
+	if _, err := FunderAddress(eoa, SignatureType(9)); err == nil {
+		t.Error("expected an unsupported signature type error")
+	}
+}
+
+func TestVerifyFunder(t *testing.T) {
+	c, err := NewPolymarketClient("k", testSecret, "p", testPrivateKey, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SignatureType = SignaturePolyProxy
+
+	derived, err := c.DeriveFunder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if derived != ProxyWalletAddress(c.signerAddress()) {
+		t.Errorf("derived funder %s is not the proxy wallet", derived.Hex())
+	}
+
+	if err := c.VerifyFunder(); err == nil {
+		t.Error("expected an empty funder to fail verification")
+	}
+	c.Funder = derived
+	if err := c.VerifyFunder(); err != nil {
+		t.Error(err)
+	}
+	// The Safe of the same owner is another wallet
+	c.SignatureType = SignatureGnosisSafe
+	if err := c.VerifyFunder(); err == nil {
+		t.Error("expected the proxy address to fail verification as a Safe")
+	}
+}
@@ -0,0 +1,140 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket endpoints of the CLOB
+const (
+	MarketChannelURL = "wss://ws-subscriptions-clob.polymarket.com/ws/market"
+	UserChannelURL   = "wss://ws-subscriptions-clob.polymarket.com/ws/user"
+)
+
+// wsConn is a self-healing WebSocket subscription: it dials, sends the subscription
+// message, pings to keep the connection open and redials with exponential backoff.
+type wsConn struct {
+	url          string
+	minBackoff   time.Duration
+	maxBackoff   time.Duration
+	pingInterval time.Duration
+
+	// subscribe builds the message sent right after every (re)connect
+	subscribe func() interface{}
+	// handle is called for every data message
+	handle func([]byte)
+	// disconnected is called when a connection drops, before redialing
+	disconnected func(error)
+
+	mu   sync.Mutex // Guards conn and serializes writes
+	conn *websocket.Conn
+}
+
+func newWSConn(url string) *wsConn {
+	return &wsConn{
+		url:          url,
+		minBackoff:   500 * time.Millisecond,
+		maxBackoff:   30 * time.Second,
+		pingInterval: 10 * time.Second, // The server drops connections silent for longer
+	}
+}
+
+// run keeps the subscription alive until ctx is cancelled
+func (w *wsConn) run(ctx context.Context) error {
+	backoff := w.minBackoff
+	for {
+		connected, err := w.session(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if connected {
+			backoff = w.minBackoff
+		}
+		if w.disconnected != nil {
+			w.disconnected(err)
+		}
+		log.Printf("websocket %s disconnected: %v (retrying in %v)", w.url, err, backoff)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > w.maxBackoff {
+			backoff = w.maxBackoff
+		}
+	}
+}
+
+// session runs a single connection, reporting whether the subscription went through
+func (w *wsConn) session(ctx context.Context) (bool, error) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, w.url, nil)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	w.mu.Lock()
+	w.conn = conn
+	w.mu.Unlock()
+	defer func() {
+		w.mu.Lock()
+		w.conn = nil
+		w.mu.Unlock()
+	}()
+
+	if err := w.send(w.subscribe()); err != nil {
+		return false, err
+	}
+
+	// Unblock the reader on shutdown and keep the connection alive
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ping := time.NewTicker(w.pingInterval)
+		defer ping.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				conn.Close()
+				return
+			case <-done:
+				return
+			case <-ping.C:
+				w.mu.Lock()
+				err := conn.WriteMessage(websocket.TextMessage, []byte("PING"))
+				w.mu.Unlock()
+				if err != nil {
+					conn.Close()
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return true, err
+		}
+		if string(msg) == "PONG" {
+			continue
+		}
+		w.handle(msg)
+	}
+}
+
+// send writes a JSON message on the current connection
+func (w *wsConn) send(v interface{}) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn == nil {
+		return errors.New("websocket not connected")
+	}
+	return w.conn.WriteJSON(v)
+}
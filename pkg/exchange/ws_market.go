@@ -0,0 +1,336 @@
+package exchange
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// OrderBook is a local copy of one outcome token's book, keyed by exact price level
+type OrderBook struct {
+	AssetID   string
+	Bids      map[Amount]Amount // Price -> size
+	Asks      map[Amount]Amount
+	Timestamp time.Time
+}
+
+func newOrderBook(assetID string) *OrderBook {
+	return &OrderBook{
+		AssetID: assetID,
+		Bids:    make(map[Amount]Amount),
+		Asks:    make(map[Amount]Amount),
+	}
+}
+
+// BestAsk returns the lowest ask
+func (b *OrderBook) BestAsk() (Amount, bool) {
+	best, found := Amount(0), false
+	for p := range b.Asks {
+		if !found || p < best {
+			best, found = p, true
+		}
+	}
+	return best, found
+}
+
+// BestBid returns the highest bid
+func (b *OrderBook) BestBid() (Amount, bool) {
+	best, found := Amount(0), false
+	for p := range b.Bids {
+		if !found || p > best {
+			best, found = p, true
+		}
+	}
+	return best, found
+}
+
+// setLevel updates one price level; a zero size removes it
+func (b *OrderBook) setLevel(side string, price, size string) {
+	p, err := ParseAmount(price)
+	if err != nil {
+		return
+	}
+	s, err := ParseAmount(size)
+	if err != nil {
+		return
+	}
+
+	levels := b.Bids
+	if side == "SELL" {
+		levels = b.Asks
+	}
+	if s == 0 {
+		delete(levels, p)
+	} else {
+		levels[p] = s
+	}
+}
+
+func (b *OrderBook) copy() *OrderBook {
+	cp := newOrderBook(b.AssetID)
+	cp.Timestamp = b.Timestamp
+	for p, s := range b.Bids {
+		cp.Bids[p] = s
+	}
+	for p, s := range b.Asks {
+		cp.Asks[p] = s
+	}
+	return cp
+}
+
+// MarketStream maintains local order books over the CLOB market WebSocket channel
+// and publishes a Ticker whenever the best asks of a subscribed market change.
+type MarketStream struct {
+	ws *wsConn
+
+	mu      sync.Mutex
+	owners  map[string]*Market // Token ID -> market
+	books   map[string]*OrderBook
+	tickers chan *Ticker
+}
+
+// NewMarketStream creates a stream for the given markets; call Run to connect
+func NewMarketStream(url string, markets ...Market) *MarketStream {
+	s := &MarketStream{
+		owners:  make(map[string]*Market),
+		books:   make(map[string]*OrderBook),
+		tickers: make(chan *Ticker, 64),
+	}
+	for _, m := range markets {
+		s.addMarket(m)
+	}
+
+	s.ws = newWSConn(url)
+	s.ws.subscribe = s.subscription
+	s.ws.handle = s.handleMessage
+	s.ws.disconnected = func(error) { s.resetBooks() }
+	return s
+}
+
+// Tickers delivers the latest prices; stale tickers are dropped when the reader lags
+func (s *MarketStream) Tickers() <-chan *Ticker {
+	return s.tickers
+}
+
+// Run connects and keeps the books in sync until ctx is cancelled
+func (s *MarketStream) Run(ctx context.Context) error {
+	return s.ws.run(ctx)
+}
+
+// Subscribe adds a market, subscribing to its tokens right away when connected
+func (s *MarketStream) Subscribe(m Market) {
+	s.addMarket(m)
+	err := s.ws.send(map[string]interface{}{
+		"assets_ids": []string{m.TokenUp, m.TokenDown},
+		"operation":  "subscribe",
+	})
+	if err != nil {
+		// Not connected: the next (re)connect subscribes to every market
+		log.Printf("market stream: deferred subscription to %s: %v", m.ID, err)
+	}
+}
+
+// Unsubscribe drops a market and the books of its tokens, e.g. once its round is over
+func (s *MarketStream) Unsubscribe(m Market) {
+	s.mu.Lock()
+	for _, token := range []string{m.TokenUp, m.TokenDown} {
+		delete(s.owners, token)
+		delete(s.books, token)
+	}
+	s.mu.Unlock()
+	// Not connected: the next (re)connect leaves it out anyway
+	s.ws.send(map[string]interface{}{
+		"assets_ids": []string{m.TokenUp, m.TokenDown},
+		"operation":  "unsubscribe",
+	})
+}
+
+// Book returns a snapshot of the local book of a token
+func (s *MarketStream) Book(assetID string) (*OrderBook, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.books[assetID]
+	if !ok {
+		return nil, false
+	}
+	return b.copy(), true
+}
+
+func (s *MarketStream) addMarket(m Market) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.owners[m.TokenUp] = &m
+	s.owners[m.TokenDown] = &m
+}
+
+// subscription is the initial message for every connection
+func (s *MarketStream) subscription() interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	assets := make([]string, 0, len(s.owners))
+	for id := range s.owners {
+		assets = append(assets, id)
+	}
+	return map[string]interface{}{
+		"assets_ids": assets,
+		"type":       "market",
+	}
+}
+
+// resetBooks drops the local books; they are rebuilt from the snapshots sent on reconnect
+func (s *MarketStream) resetBooks() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.books = make(map[string]*OrderBook)
+}
+
+type bookLevel struct {
+	Price string `json:"price"`
+	Size  string `json:"size"`
+}
+
+type priceChange struct {
+	AssetID string `json:"asset_id"`
+	Price   string `json:"price"`
+	Size    string `json:"size"`
+	Side    string `json:"side"`
+}
+
+// marketEvent covers the market channel messages we use
+type marketEvent struct {
+	EventType string      `json:"event_type"`
+	AssetID   string      `json:"asset_id"`
+	Market    string      `json:"market"`
+	Timestamp string      `json:"timestamp"`
+	Bids      []bookLevel `json:"bids"`
+	Asks      []bookLevel `json:"asks"`
+
+	PriceChanges []priceChange `json:"price_changes"`
+	Changes      []priceChange `json:"changes"` // Legacy single-asset format
+}
+
+func (s *MarketStream) handleMessage(msg []byte) {
+	// Snapshots arrive as an array of events, updates as single objects
+	var events []marketEvent
+	if bytes.HasPrefix(bytes.TrimSpace(msg), []byte("[")) {
+		if err := json.Unmarshal(msg, &events); err != nil {
+			log.Printf("market stream: bad message: %v", err)
+			return
+		}
+	} else {
+		var ev marketEvent
+		if err := json.Unmarshal(msg, &ev); err != nil {
+			log.Printf("market stream: bad message: %v", err)
+			return
+		}
+		events = append(events, ev)
+	}
+
+	for i := range events {
+		s.applyEvent(&events[i])
+	}
+}
+
+func (s *MarketStream) applyEvent(ev *marketEvent) {
+	ts := parseEventTime(ev.Timestamp)
+	touched := make(map[string]bool)
+
+	s.mu.Lock()
+	switch ev.EventType {
+	case "book":
+		b := newOrderBook(ev.AssetID)
+		for _, l := range ev.Bids {
+			b.setLevel("BUY", l.Price, l.Size)
+		}
+		for _, l := range ev.Asks {
+			b.setLevel("SELL", l.Price, l.Size)
+		}
+		b.Timestamp = ts
+		s.books[ev.AssetID] = b
+		touched[ev.AssetID] = true
+	case "price_change":
+		changes := ev.PriceChanges
+		for _, c := range ev.Changes {
+			c.AssetID = ev.AssetID
+			changes = append(changes, c)
+		}
+		for _, c := range changes {
+			b, ok := s.books[c.AssetID]
+			if !ok {
+				continue // Deltas are meaningless before the snapshot
+			}
+			b.setLevel(c.Side, c.Price, c.Size)
+			b.Timestamp = ts
+			touched[c.AssetID] = true
+		}
+	}
+
+	var tickers []*Ticker
+	seen := make(map[string]bool)
+	for assetID := range touched {
+		m, ok := s.owners[assetID]
+		if !ok || seen[m.ID] {
+			continue
+		}
+		seen[m.ID] = true
+		if t := s.tickerLocked(m, ts); t != nil {
+			tickers = append(tickers, t)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, t := range tickers {
+		s.publish(t)
+	}
+}
+
+// tickerLocked builds a ticker once both books of the market have asks
+func (s *MarketStream) tickerLocked(m *Market, ts time.Time) *Ticker {
+	up, okUp := s.books[m.TokenUp]
+	down, okDown := s.books[m.TokenDown]
+	if !okUp || !okDown {
+		return nil
+	}
+	askUp, okUp := up.BestAsk()
+	askDown, okDown := down.BestAsk()
+	if !okUp || !okDown {
+		return nil
+	}
+	return &Ticker{
+		MarketID:  m.ID,
+		PriceUp:   askUp.Float64(),
+		PriceDown: askDown.Float64(),
+		Timestamp: ts,
+	}
+}
+
+// publish delivers a ticker, discarding the oldest one when the channel is full
+func (s *MarketStream) publish(t *Ticker) {
+	for {
+		select {
+		case s.tickers <- t:
+			return
+		default:
+		}
+		select {
+		case <-s.tickers:
+		default:
+		}
+	}
+}
+
+// parseEventTime parses the WebSocket timestamps, sent in milliseconds or (older events) seconds
+func parseEventTime(v string) time.Time {
+	ts, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || ts == 0 {
+		return time.Now()
+	}
+	if ts < 1e12 {
+		return time.Unix(ts, 0)
+	}
+	return time.UnixMilli(ts)
+}
@@ -0,0 +1,200 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// newTestWSServer serves a WebSocket stand-in; each connection is handed to serve
+func newTestWSServer(t *testing.T, serve func(conn *websocket.Conn)) string {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		defer conn.Close()
+		serve(conn)
+	}))
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func nextTicker(t *testing.T, s *MarketStream) *Ticker {
+	select {
+	case tk := <-s.Tickers():
+		return tk
+	case <-time.After(2 * time.Second):
+		t.Fatal("no ticker received")
+		return nil
+	}
+}
+
+var testMarket = Market{ID: "0xcond", TokenUp: "111", TokenDown: "222"}
+
+func TestMarketStreamBooks(t *testing.T) {
+	subscribed := make(chan map[string]interface{}, 1)
+	url := newTestWSServer(t, func(conn *websocket.Conn) {
+		var sub map[string]interface{}
+		if err := conn.ReadJSON(&sub); err != nil {
+			return
+		}
+		subscribed <- sub
+
+		conn.WriteMessage(websocket.TextMessage, []byte(`[
+			{"event_type":"book","asset_id":"111","market":"0xcond","timestamp":"1700000000000",
+			 "bids":[{"price":"0.48","size":"30"}],"asks":[{"price":"0.52","size":"25"},{"price":"0.51","size":"10"}]},
+			{"event_type":"book","asset_id":"222","market":"0xcond","timestamp":"1700000000000",
+			 "bids":[{"price":"0.47","size":"30"}],"asks":[{"price":"0.50","size":"40"}]}
+		]`))
+		// Best UP ask is taken out, DOWN gets a better ask
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"event_type":"price_change","market":"0xcond","timestamp":"1700000000500",
+			"price_changes":[{"asset_id":"111","price":"0.51","size":"0","side":"SELL"},
+			                 {"asset_id":"222","price":"0.49","size":"15","side":"SELL"}]}`))
+		// Legacy format
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"event_type":"price_change","asset_id":"111","market":"0xcond","timestamp":"1700000001000",
+			"changes":[{"price":"0.50","size":"5","side":"SELL"}]}`))
+
+		conn.ReadMessage() // Hold the connection open
+	})
+
+	s := NewMarketStream(url, testMarket)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	sub := <-subscribed
+	if sub["type"] != "market" || len(sub["assets_ids"].([]interface{})) != 2 {
+		t.Errorf("subscription = %v", sub)
+	}
+
+	// The snapshot yields a ticker once both books are known
+	tk := nextTicker(t, s)
+	if tk.MarketID != "0xcond" || tk.PriceUp != 0.51 || tk.PriceDown != 0.50 {
+		t.Errorf("snapshot ticker = %+v", tk)
+	}
+	if !tk.Timestamp.Equal(time.UnixMilli(1700000000000)) {
+		t.Errorf("timestamp = %v", tk.Timestamp)
+	}
+
+	tk = nextTicker(t, s)
+	if tk.PriceUp != 0.52 || tk.PriceDown != 0.49 {
+		t.Errorf("delta ticker = %+v", tk)
+	}
+
+	tk = nextTicker(t, s)
+	if tk.PriceUp != 0.50 {
+		t.Errorf("legacy delta ticker = %+v", tk)
+	}
+
+	book, ok := s.Book("111")
+	if !ok {
+		t.Fatal("missing book")
+	}
+	if bid, _ := book.BestBid(); bid.String() != "0.48" {
+		t.Errorf("best bid = %s", bid)
+	}
+	if len(book.Asks) != 2 {
+		t.Errorf("asks = %v", book.Asks)
+	}
+}
+
+func TestMarketStreamResubscribes(t *testing.T) {
+	subs := make(chan []string, 4)
+	var conns int32
+	url := newTestWSServer(t, func(conn *websocket.Conn) {
+		n := atomic.AddInt32(&conns, 1)
+		var sub struct {
+			AssetsIDs []string `json:"assets_ids"`
+		}
+		if err := conn.ReadJSON(&sub); err != nil {
+			return
+		}
+		subs <- sub.AssetsIDs
+
+		if n == 1 {
+			return // Drop the first connection right away
+		}
+		msg, _ := json.Marshal([]map[string]interface{}{
+			{"event_type": "book", "asset_id": "111", "asks": []bookLevel{{Price: "0.6", Size: "1"}}},
+			{"event_type": "book", "asset_id": "222", "asks": []bookLevel{{Price: "0.3", Size: "1"}}},
+		})
+		conn.WriteMessage(websocket.TextMessage, msg)
+		conn.ReadMessage()
+	})
+
+	s := NewMarketStream(url, testMarket)
+	s.ws.minBackoff = 10 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case ids := <-subs:
+			if len(ids) != 2 {
+				t.Errorf("subscription %d = %v", i, ids)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("no subscription on connection %d", i+1)
+		}
+	}
+
+	tk := nextTicker(t, s)
+	if tk.PriceUp != 0.6 || tk.PriceDown != 0.3 {
+		t.Errorf("ticker after reconnect = %+v", tk)
+	}
+}
+
+func TestMarketStreamUnsubscribes(t *testing.T) {
+	unsubscribed := make(chan map[string]interface{}, 1)
+	url := newTestWSServer(t, func(conn *websocket.Conn) {
+		var sub map[string]interface{}
+		if err := conn.ReadJSON(&sub); err != nil {
+			return
+		}
+		msg, _ := json.Marshal([]map[string]interface{}{
+			{"event_type": "book", "asset_id": "111", "asks": []bookLevel{{Price: "0.6", Size: "1"}}},
+			{"event_type": "book", "asset_id": "222", "asks": []bookLevel{{Price: "0.3", Size: "1"}}},
+		})
+		conn.WriteMessage(websocket.TextMessage, msg)
+
+		var unsub map[string]interface{}
+		if err := conn.ReadJSON(&unsub); err != nil {
+			return
+		}
+		unsubscribed <- unsub
+		conn.ReadMessage()
+	})
+
+	s := NewMarketStream(url, testMarket)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+	nextTicker(t, s)
+
+	s.Unsubscribe(testMarket)
+	select {
+	case unsub := <-unsubscribed:
+		if unsub["operation"] != "unsubscribe" || len(unsub["assets_ids"].([]interface{})) != 2 {
+			t.Errorf("unsubscription = %v", unsub)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no unsubscription sent")
+	}
+	if _, ok := s.Book("111"); ok {
+		t.Error("book of an unsubscribed token kept")
+	}
+	if ids := s.subscription().(map[string]interface{})["assets_ids"].([]string); len(ids) != 0 {
+		t.Errorf("reconnect would resubscribe to %v", ids)
+	}
+}
@@ -0,0 +1,214 @@
+package exchange
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// UserStream delivers the fills and order updates of the account over the
+// authenticated CLOB user WebSocket channel.
+type UserStream struct {
+	ws    *wsConn
+	creds APICredentials
+
+	mu      sync.Mutex
+	markets map[string]bool    // Condition IDs subscribed to
+	owners  map[string]*Market // Token ID -> market, to resolve sides
+
+	events chan *UserEvent
+	done   <-chan struct{}
+}
+
+// NewUserStream creates a stream for the given markets; call Run to connect
+func NewUserStream(url string, creds APICredentials, markets ...Market) *UserStream {
+	s := &UserStream{
+		creds:   creds,
+		markets: make(map[string]bool),
+		owners:  make(map[string]*Market),
+		events:  make(chan *UserEvent, 256),
+	}
+	for _, m := range markets {
+		s.addMarket(m)
+	}
+
+	s.ws = newWSConn(url)
+	s.ws.subscribe = s.subscription
+	s.ws.handle = s.handleMessage
+	return s
+}
+
+// UserEvents implements UserEventSource. Events are never dropped, so the
+// stream stalls until they are read.
+func (s *UserStream) UserEvents() <-chan *UserEvent {
+	return s.events
+}
+
+// Run connects and delivers events until ctx is cancelled
+func (s *UserStream) Run(ctx context.Context) error {
+	s.done = ctx.Done()
+	return s.ws.run(ctx)
+}
+
+// Subscribe adds a market, subscribing to it right away when connected
+func (s *UserStream) Subscribe(m Market) {
+	s.addMarket(m)
+	err := s.ws.send(map[string]interface{}{
+		"markets":   []string{m.ID},
+		"operation": "subscribe",
+	})
+	if err != nil {
+		// Not connected: the next (re)connect subscribes to every market
+		log.Printf("user stream: deferred subscription to %s: %v", m.ID, err)
+	}
+}
+
+// Unsubscribe drops a market, e.g. once its round is over
+func (s *UserStream) Unsubscribe(m Market) {
+	s.mu.Lock()
+	delete(s.markets, m.ID)
+	delete(s.owners, m.TokenUp)
+	delete(s.owners, m.TokenDown)
+	s.mu.Unlock()
+	// Not connected: the next (re)connect leaves it out anyway
+	s.ws.send(map[string]interface{}{
+		"markets":   []string{m.ID},
+		"operation": "unsubscribe",
+	})
+}
+
+func (s *UserStream) addMarket(m Market) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.markets[m.ID] = true
+	s.owners[m.TokenUp] = &m
+	s.owners[m.TokenDown] = &m
+}
+
+// subscription authenticates with the L2 credentials; an empty market list
+// subscribes to every market of the account
+func (s *UserStream) subscription() interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	markets := make([]string, 0, len(s.markets))
+	for id := range s.markets {
+		markets = append(markets, id)
+	}
+	return map[string]interface{}{
+		"auth": map[string]string{
+			"apiKey":     s.creds.APIKey,
+			"secret":     s.creds.Secret,
+			"passphrase": s.creds.Passphrase,
+		},
+		"markets": markets,
+		"type":    "user",
+	}
+}
+
+// side resolves the outcome of a token id
+func (s *UserStream) side(assetID string) Side {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.owners[assetID]
+	if !ok {
+		return ""
+	}
+	if m.TokenDown == assetID {
+		return SideDown
+	}
+	return SideUp
+}
+
+// userMessage covers the trade and order events of the user channel. Order events
+// share the id, market, asset, owner, side and price fields of trades.
+type userMessage struct {
+	EventType string `json:"event_type"`
+	clobTrade
+	Timestamp string `json:"timestamp"`
+
+	// Order
+	Type         string `json:"type"` // PLACEMENT, UPDATE or CANCELLATION
+	OriginalSize string `json:"original_size"`
+	SizeMatched  string `json:"size_matched"`
+}
+
+func (s *UserStream) handleMessage(msg []byte) {
+	var msgs []userMessage
+	if bytes.HasPrefix(bytes.TrimSpace(msg), []byte("[")) {
+		if err := json.Unmarshal(msg, &msgs); err != nil {
+			log.Printf("user stream: bad message: %v", err)
+			return
+		}
+	} else {
+		var m userMessage
+		if err := json.Unmarshal(msg, &m); err != nil {
+			log.Printf("user stream: bad message: %v", err)
+			return
+		}
+		msgs = append(msgs, m)
+	}
+
+	for i := range msgs {
+		for _, ev := range s.toEvents(&msgs[i]) {
+			select {
+			case s.events <- ev:
+			case <-s.done:
+				return
+			}
+		}
+	}
+}
+
+// toEvents converts a message into one event per order of ours it involves
+func (s *UserStream) toEvents(m *userMessage) []*UserEvent {
+	ts := parseEventTime(m.Timestamp)
+
+	switch m.EventType {
+	case "order":
+		size, _ := strconv.ParseFloat(m.OriginalSize, 64)
+		matched, _ := strconv.ParseFloat(m.SizeMatched, 64)
+		price, _ := strconv.ParseFloat(m.Price, 64)
+
+		status := parseOrderStatus("", matched)
+		if strings.ToUpper(m.Type) == "CANCELLATION" {
+			status = OrderCancelled
+		} else if size > 0 && matched >= size {
+			status = OrderMatched
+		}
+
+		return []*UserEvent{{
+			Type:        UserEventOrder,
+			MarketID:    m.Market,
+			OrderID:     m.ID,
+			Side:        s.side(m.AssetID),
+			Direction:   Direction(strings.ToUpper(m.Side)),
+			Price:       price,
+			Timestamp:   ts,
+			OrderStatus: status,
+			SizeMatched: matched,
+		}}
+
+	case "trade":
+		var events []*UserEvent
+		for _, f := range m.ownFills(s.creds.APIKey) {
+			events = append(events, &UserEvent{
+				Type:        UserEventTrade,
+				MarketID:    m.Market,
+				OrderID:     f.orderID,
+				Side:        s.side(f.assetID),
+				Direction:   f.direction,
+				Price:       f.price,
+				Timestamp:   ts,
+				TradeID:     m.ID,
+				TradeStatus: TradeStatus(strings.ToUpper(m.Status)),
+				Size:        f.size,
+			})
+		}
+		return events
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package exchange
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func nextUserEvent(t *testing.T, s *UserStream) *UserEvent {
+	select {
+	case ev := <-s.UserEvents():
+		return ev
+	case <-time.After(2 * time.Second):
+		t.Fatal("no user event received")
+		return nil
+	}
+}
+
+func TestUserStreamEvents(t *testing.T) {
+	creds := APICredentials{APIKey: "my-key", Secret: testSecret, Passphrase: "pass"}
+
+	type subscription struct {
+		Auth    map[string]string `json:"auth"`
+		Markets []string          `json:"markets"`
+		Type    string            `json:"type"`
+	}
+	subscribed := make(chan subscription, 1)
+	url := newTestWSServer(t, func(conn *websocket.Conn) {
+		var sub subscription
+		if err := conn.ReadJSON(&sub); err != nil {
+			return
+		}
+		subscribed <- sub
+
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"event_type":"order","type":"PLACEMENT","id":"0xorder1",
+			"market":"0xcond","asset_id":"222","owner":"my-key","side":"BUY","price":"0.45",
+			"original_size":"10","size_matched":"0","timestamp":"1700000000"}`))
+		// Our resting order is one of the makers; the taker belongs to someone else
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"event_type":"trade","id":"trade-1","status":"MATCHED",
+			"market":"0xcond","asset_id":"111","owner":"other-key","side":"SELL","price":"0.55","size":"10",
+			"taker_order_id":"0xtheirs","timestamp":"1700000001000",
+			"maker_orders":[{"order_id":"0xother","owner":"other-key","asset_id":"222","matched_amount":"6","price":"0.45","side":"BUY"},
+			                {"order_id":"0xorder1","owner":"my-key","asset_id":"222","matched_amount":"4","price":"0.45","side":"BUY"}]}`))
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"event_type":"trade","id":"trade-1","status":"FAILED",
+			"market":"0xcond","owner":"other-key","taker_order_id":"0xtheirs","timestamp":"1700000002000",
+			"maker_orders":[{"order_id":"0xorder1","owner":"my-key","asset_id":"222","matched_amount":"4","price":"0.45","side":"BUY"}]}`))
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"event_type":"order","type":"CANCELLATION","id":"0xorder1",
+			"market":"0xcond","asset_id":"222","owner":"my-key","side":"BUY","price":"0.45",
+			"original_size":"10","size_matched":"4","timestamp":"1700000003000"}`))
+
+		conn.ReadMessage()
+	})
+
+	s := NewUserStream(url, creds, testMarket)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	sub := <-subscribed
+	if sub.Type != "user" || sub.Auth["apiKey"] != "my-key" || sub.Auth["passphrase"] != "pass" || sub.Auth["secret"] != testSecret {
+		t.Errorf("subscription = %+v", sub)
+	}
+	if len(sub.Markets) != 1 || sub.Markets[0] != "0xcond" {
+		t.Errorf("subscribed markets = %v", sub.Markets)
+	}
+
+	ev := nextUserEvent(t, s)
+	if ev.Type != UserEventOrder || ev.OrderID != "0xorder1" || ev.OrderStatus != OrderLive || ev.Side != SideDown {
+		t.Errorf("placement = %+v", ev)
+	}
+	if !ev.Timestamp.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("placement timestamp = %v", ev.Timestamp)
+	}
+
+	ev = nextUserEvent(t, s)
+	if ev.Type != UserEventTrade || ev.TradeStatus != TradeMatched || ev.OrderID != "0xorder1" ||
+		ev.Size != 4 || ev.Price != 0.45 || ev.Direction != DirectionBuy {
+		t.Errorf("maker fill = %+v", ev)
+	}
+
+	ev = nextUserEvent(t, s)
+	if ev.TradeStatus != TradeFailed || ev.TradeID != "trade-1" {
+		t.Errorf("failed trade = %+v", ev)
+	}
+
+	ev = nextUserEvent(t, s)
+	if ev.OrderStatus != OrderCancelled || ev.SizeMatched != 4 {
+		t.Errorf("cancellation = %+v", ev)
+	}
+}
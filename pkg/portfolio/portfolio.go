@@ -0,0 +1,238 @@
+package portfolio
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"poly/pkg/exchange"
+)
+
+// Position is the holding of one outcome of a market
+type Position struct {
+	MarketID string
+	Side     exchange.Side
+	Shares   exchange.Amount
+	Cost     exchange.Amount // Cost basis of the shares still held
+	Realized exchange.Amount // Profit locked in by sells
+}
+
+// AvgCost returns the average price paid per share held
+func (p *Position) AvgCost() exchange.Amount {
+	return p.Cost.Quo(p.Shares, exchange.RoundHalfUp)
+}
+
+// Unrealized returns the profit of the shares held if sold at mark
+func (p *Position) Unrealized(mark exchange.Amount) exchange.Amount {
+	return p.Shares.Mul(mark, exchange.RoundHalfUp).Sub(p.Cost)
+}
+
+// Marks prices the outcomes of a market, e.g. an exchange.Ticker
+type Marks interface {
+	Price(side exchange.Side) exchange.Amount
+}
+
+// PnL is the profit and loss of a market
+type PnL struct {
+	Realized   exchange.Amount
+	Unrealized exchange.Amount
+}
+
+func (p PnL) Total() exchange.Amount {
+	return p.Realized.Add(p.Unrealized)
+}
+
+type positionKey struct {
+	marketID string
+	side     exchange.Side
+}
+
+// fill is what a counted trade changed in its position, so it can be taken back
+type fill struct {
+	key                    positionKey
+	shares, cost, realized exchange.Amount
+}
+
+// Portfolio tracks the shares held per market and outcome from the account's fills.
+// Costs are averaged: a sell realizes its proceeds against the average cost.
+type Portfolio struct {
+	mu        sync.RWMutex
+	positions map[positionKey]*Position
+	applied   map[string]*fill // Fills already seen, by trade and order; nil once failed
+}
+
+func New() *Portfolio {
+	return &Portfolio{
+		positions: make(map[positionKey]*Position),
+		applied:   make(map[string]*fill),
+	}
+}
+
+// Apply records a fill. A fill seen again (e.g. as MATCHED, then CONFIRMED) is only
+// counted once, and one that fails after being counted is taken back.
+func (p *Portfolio) Apply(t *exchange.Trade) {
+	if t.Size <= 0 {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	id := t.ID + "/" + t.OrderID
+	f, seen := p.applied[id]
+	if t.Status == exchange.TradeFailed {
+		if f != nil {
+			pos := p.positions[f.key]
+			pos.Shares = pos.Shares.Sub(f.shares)
+			pos.Cost = pos.Cost.Sub(f.cost)
+			pos.Realized = pos.Realized.Sub(f.realized)
+		}
+		p.applied[id] = nil // Never count it, whatever status comes late
+		return
+	}
+	if seen {
+		return
+	}
+
+	key := positionKey{t.MarketID, t.Side}
+	pos, ok := p.positions[key]
+	if !ok {
+		pos = &Position{MarketID: t.MarketID, Side: t.Side}
+		p.positions[key] = pos
+	}
+	before := *pos
+
+	shares := exchange.AmountFromFloat(t.Size)
+	value := exchange.AmountFromFloat(t.Price).Mul(shares, exchange.RoundHalfUp)
+	if t.Direction == exchange.DirectionBuy {
+		pos.Shares = pos.Shares.Add(shares)
+		pos.Cost = pos.Cost.Add(value)
+	} else {
+		if shares > pos.Shares {
+			shares = pos.Shares // Shares bought outside the portfolio have no known cost
+		}
+		pos.close(shares, value)
+	}
+	p.applied[id] = &fill{
+		key:      key,
+		shares:   pos.Shares.Sub(before.Shares),
+		cost:     pos.Cost.Sub(before.Cost),
+		realized: pos.Realized.Sub(before.Realized),
+	}
+}
+
+// basis returns the average cost of shares out of the position
+func (pos *Position) basis(shares exchange.Amount) exchange.Amount {
+	if shares >= pos.Shares {
+		return pos.Cost
+	}
+	return pos.Cost.Mul(shares, exchange.RoundHalfUp).Quo(pos.Shares, exchange.RoundHalfUp)
+}
+
+// close removes shares from the position, realizing value against their basis
+func (pos *Position) close(shares, value exchange.Amount) {
+	basis := pos.basis(shares)
+	pos.Shares = pos.Shares.Sub(shares)
+	pos.Cost = pos.Cost.Sub(basis)
+	pos.Realized = pos.Realized.Add(value.Sub(basis))
+}
+
+// Merge records pairs of UP and DOWN shares merged back into $1 of collateral each.
+// The payout is split between the sides in proportion to their cost, so each side
+// realizes the same return. It returns the pairs actually merged, capped by the holdings.
+func (p *Portfolio) Merge(marketID string, pairs exchange.Amount) exchange.Amount {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	up, okUp := p.positions[positionKey{marketID, exchange.SideUp}]
+	down, okDown := p.positions[positionKey{marketID, exchange.SideDown}]
+	if !okUp || !okDown {
+		return 0
+	}
+	pairs = min(pairs, up.Shares, down.Shares)
+	if pairs <= 0 {
+		return 0
+	}
+
+	payout := pairs // $1 per pair
+	basisUp, basisDown := up.basis(pairs), down.basis(pairs)
+	payoutUp := payout.Quo(exchange.AmountFromFloat(2), exchange.RoundHalfUp)
+	if total := basisUp.Add(basisDown); total > 0 {
+		payoutUp = payout.Mul(basisUp, exchange.RoundHalfUp).Quo(total, exchange.RoundHalfUp)
+	}
+	up.close(pairs, payoutUp)
+	down.close(pairs, payout.Sub(payoutUp))
+	return pairs
+}
+
+// Redeem records the redemption of every share held in a resolved market at the
+// payout of its outcome, and returns the collateral paid out
+func (p *Portfolio) Redeem(marketID string, payouts Marks) exchange.Amount {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var total exchange.Amount
+	for _, side := range []exchange.Side{exchange.SideUp, exchange.SideDown} {
+		pos, ok := p.positions[positionKey{marketID, side}]
+		if !ok || pos.Shares <= 0 {
+			continue
+		}
+		value := pos.Shares.Mul(payouts.Price(side), exchange.RoundDown)
+		pos.close(pos.Shares, value)
+		total = total.Add(value)
+	}
+	return total
+}
+
+// Load applies the account's past fills in a market, e.g. after a restart
+func (p *Portfolio) Load(ctx context.Context, ex exchange.Exchange, marketID string) error {
+	trades, err := ex.GetTrades(ctx, marketID)
+	if err != nil {
+		return err
+	}
+	for _, t := range trades {
+		p.Apply(t)
+	}
+	return nil
+}
+
+// Position returns the holding of one outcome; the zero Position when there is none
+func (p *Portfolio) Position(marketID string, side exchange.Side) Position {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if pos, ok := p.positions[positionKey{marketID, side}]; ok {
+		return *pos
+	}
+	return Position{MarketID: marketID, Side: side}
+}
+
+// Positions returns every position, including closed ones, ordered by market and side
+func (p *Portfolio) Positions() []Position {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	positions := make([]Position, 0, len(p.positions))
+	for _, pos := range p.positions {
+		positions = append(positions, *pos)
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		if positions[i].MarketID != positions[j].MarketID {
+			return positions[i].MarketID < positions[j].MarketID
+		}
+		return positions[i].Side < positions[j].Side
+	})
+	return positions
+}
+
+// MarketPnL returns the realized P&L of a market and the unrealized P&L of its
+// holdings valued at marks
+func (p *Portfolio) MarketPnL(marketID string, marks Marks) PnL {
+	var pnl PnL
+	for _, side := range []exchange.Side{exchange.SideUp, exchange.SideDown} {
+		pos := p.Position(marketID, side)
+		pnl.Realized = pnl.Realized.Add(pos.Realized)
+		if pos.Shares > 0 {
+			pnl.Unrealized = pnl.Unrealized.Add(pos.Unrealized(marks.Price(side)))
+		}
+	}
+	return pnl
+}
@@ -0,0 +1,128 @@
+package portfolio
+
+import (
+	"context"
+	"testing"
+
+	"poly/pkg/exchange"
+)
+
+func amt(f float64) exchange.Amount { return exchange.AmountFromFloat(f) }
+
+func TestPortfolio(t *testing.T) {
+	p := New()
+	buy := func(id string, side exchange.Side, price, size float64) *exchange.Trade {
+		return &exchange.Trade{ID: id, OrderID: "o-" + id, MarketID: "m", Side: side, Direction: exchange.DirectionBuy,
+			Price: price, Size: size, Status: exchange.TradeConfirmed}
+	}
+
+	p.Apply(buy("1", exchange.SideUp, 0.40, 10))
+	p.Apply(buy("2", exchange.SideUp, 0.50, 10))
+	p.Apply(buy("2", exchange.SideUp, 0.50, 10)) // Same fill reported again
+	failed := buy("3", exchange.SideUp, 0.30, 10)
+	failed.Status = exchange.TradeFailed
+	p.Apply(failed)
+
+	up := p.Position("m", exchange.SideUp)
+	if up.Shares != amt(20) || up.Cost != amt(9) || up.AvgCost() != amt(0.45) {
+		t.Errorf("expected 20 shares costing 9 (0.45 avg), got %+v", up)
+	}
+
+	// Selling half at 0.60 realizes 5 * (0.60 - 0.45)
+	sell := buy("4", exchange.SideUp, 0.60, 5)
+	sell.Direction = exchange.DirectionSell
+	p.Apply(sell)
+	up = p.Position("m", exchange.SideUp)
+	if up.Shares != amt(15) || up.Cost != amt(6.75) || up.Realized != amt(0.75) {
+		t.Errorf("unexpected position after the sell %+v", up)
+	}
+
+	p.Apply(buy("5", exchange.SideDown, 0.45, 15))
+	pnl := p.MarketPnL("m", &exchange.Ticker{PriceUp: 0.50, PriceDown: 0.50})
+	// UP: 15 * 0.50 - 6.75 = 0.75, DOWN: 15 * 0.50 - 6.75 = 0.75
+	if pnl.Realized != amt(0.75) || pnl.Unrealized != amt(1.5) || pnl.Total() != amt(2.25) {
+		t.Errorf("unexpected P&L %+v", pnl)
+	}
+
+	// A counted fill that fails on chain is taken back, and stays out
+	late := buy("6", exchange.SideDown, 0.40, 5)
+	p.Apply(late)
+	late.Status = exchange.TradeFailed
+	p.Apply(late)
+	late.Status = exchange.TradeConfirmed
+	p.Apply(late)
+	if down := p.Position("m", exchange.SideDown); down.Shares != amt(15) || down.Cost != amt(6.75) {
+		t.Errorf("expected the failed fill to be taken back, got %+v", down)
+	}
+
+	if n := len(p.Positions()); n != 2 {
+		t.Errorf("expected 2 positions, got %d", n)
+	}
+	if empty := p.Position("other", exchange.SideUp); empty.Shares != 0 || empty.MarketID != "other" {
+		t.Errorf("expected an empty position, got %+v", empty)
+	}
+}
+
+func TestPortfolioLoad(t *testing.T) {
+	ctx := context.Background()
+	m := exchange.NewMockExchange()
+	m.SetPrice(0.40, 0.55)
+	m.PlaceOrder(ctx, "mock-market", exchange.SideUp, exchange.DirectionBuy, 10, 0.40, exchange.OrderOptions{})
+	m.PlaceOrder(ctx, "mock-market", exchange.SideDown, exchange.DirectionBuy, 10, 0.55, exchange.OrderOptions{})
+
+	p := New()
+	if err := p.Load(ctx, m, "mock-market"); err != nil {
+		t.Fatal(err)
+	}
+	// Loading again must not double count
+	p.Load(ctx, m, "mock-market")
+
+	up, down := p.Position("mock-market", exchange.SideUp), p.Position("mock-market", exchange.SideDown)
+	if up.Shares != amt(10) || down.Shares != amt(10) || up.Cost.Add(down.Cost) != amt(9.5) {
+		t.Errorf("unexpected positions %+v %+v", up, down)
+	}
+}
+
+func TestPortfolioMerge(t *testing.T) {
+	p := New()
+	p.Apply(&exchange.Trade{ID: "1", MarketID: "m", Side: exchange.SideUp, Direction: exchange.DirectionBuy,
+		Price: 0.40, Size: 20, Status: exchange.TradeConfirmed})
+	p.Apply(&exchange.Trade{ID: "2", MarketID: "m", Side: exchange.SideDown, Direction: exchange.DirectionBuy,
+		Price: 0.55, Size: 10, Status: exchange.TradeConfirmed})
+
+	// Only 10 pairs exist; they cost 9.5 and pay 10
+	if merged := p.Merge("m", amt(20)); merged != amt(10) {
+		t.Fatalf("expected 10 pairs merged, got %s", merged)
+	}
+	up, down := p.Position("m", exchange.SideUp), p.Position("m", exchange.SideDown)
+	if up.Shares != amt(10) || up.Cost != amt(4) || down.Shares != 0 || down.Cost != 0 {
+		t.Errorf("unexpected positions after the merge %+v %+v", up, down)
+	}
+	if pnl := p.MarketPnL("m", &exchange.Ticker{PriceUp: 0.40}); pnl.Realized != amt(0.5) || pnl.Unrealized != 0 {
+		t.Errorf("expected 0.5 realized by the merge, got %+v", pnl)
+	}
+	if merged := p.Merge("m", amt(5)); merged != 0 {
+		t.Errorf("expected nothing left to merge, got %s", merged)
+	}
+}
+
+func TestPortfolioRedeem(t *testing.T) {
+	p := New()
+	p.Apply(&exchange.Trade{ID: "1", MarketID: "m", Side: exchange.SideUp, Direction: exchange.DirectionBuy,
+		Price: 0.40, Size: 20, Status: exchange.TradeConfirmed})
+	p.Apply(&exchange.Trade{ID: "2", MarketID: "m", Side: exchange.SideDown, Direction: exchange.DirectionBuy,
+		Price: 0.55, Size: 5, Status: exchange.TradeConfirmed})
+
+	// UP won: 20 * 1 paid for 8 + 2.75
+	won := &exchange.Ticker{PriceUp: 1}
+	if paid := p.Redeem("m", won); paid != amt(20) {
+		t.Errorf("expected 20 paid out, got %s", paid)
+	}
+	up, down := p.Position("m", exchange.SideUp), p.Position("m", exchange.SideDown)
+	if up.Shares != 0 || down.Shares != 0 || up.Realized != amt(12) || down.Realized != amt(-2.75) {
+		t.Errorf("unexpected positions after the redemption %+v %+v", up, down)
+	}
+	if paid := p.Redeem("m", won); paid != 0 {
+		t.Errorf("expected nothing left to redeem, got %s", paid)
+	}
+}
@@ -0,0 +1,130 @@
+package settlement
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var (
+	erc20ABI = mustABI(`[
+		{"name":"allowance","type":"function","stateMutability":"view",
+		"inputs":[{"name":"owner","type":"address"},{"name":"spender","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+		{"name":"approve","type":"function","stateMutability":"nonpayable",
+		"inputs":[{"name":"spender","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]}]`)
+
+	erc1155ABI = mustABI(`[
+		{"name":"isApprovedForAll","type":"function","stateMutability":"view",
+		"inputs":[{"name":"owner","type":"address"},{"name":"operator","type":"address"}],"outputs":[{"name":"","type":"bool"}]},
+		{"name":"setApprovalForAll","type":"function","stateMutability":"nonpayable",
+		"inputs":[{"name":"operator","type":"address"},{"name":"approved","type":"bool"}],"outputs":[]}]`)
+)
+
+// unlimitedAllowance is where an allowance counts as unlimited; USDC spends down
+// even a max approval, so it cannot be compared to MaxUint256
+var unlimitedAllowance = new(big.Int).Lsh(big.NewInt(1), 128)
+
+// Approval is one permission the exchange contracts need from the funder: spending
+// its USDC, or moving its outcome tokens
+type Approval struct {
+	Name    string
+	Token   common.Address // USDC or the CTF
+	Spender common.Address
+	Granted bool
+}
+
+// isCollateral reports whether the approval is a USDC allowance rather than a CTF operator
+func (a *Approval) isCollateral(addrs Addresses) bool {
+	return a.Token == addrs.Collateral
+}
+
+// requiredApprovals lists what trading needs: both exchanges and the neg-risk adapter
+// spend USDC and move outcome tokens
+func (s *Settler) requiredApprovals() []Approval {
+	spenders := []struct {
+		name    string
+		address common.Address
+	}{
+		{"CTF Exchange", s.Addresses.CTFExchange},
+		{"Neg Risk CTF Exchange", s.Addresses.NegRiskExchange},
+		{"Neg Risk Adapter", s.Addresses.NegRiskAdapter},
+	}
+	var approvals []Approval
+	for _, sp := range spenders {
+		approvals = append(approvals,
+			Approval{Name: "USDC -> " + sp.name, Token: s.Addresses.Collateral, Spender: sp.address},
+			Approval{Name: "CTF -> " + sp.name, Token: s.Addresses.ConditionalTokens, Spender: sp.address})
+	}
+	return approvals
+}
+
+// Approvals checks which of the approvals trading needs the funder has granted
+func (s *Settler) Approvals(ctx context.Context) ([]Approval, error) {
+	approvals := s.requiredApprovals()
+	for i := range approvals {
+		a := &approvals[i]
+		if a.isCollateral(s.Addresses) {
+			v, err := s.view(ctx, erc20ABI, a.Token, "allowance", s.Funder, a.Spender)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", a.Name, err)
+			}
+			a.Granted = v.(*big.Int).Cmp(unlimitedAllowance) >= 0
+			continue
+		}
+		v, err := s.view(ctx, erc1155ABI, a.Token, "isApprovedForAll", s.Funder, a.Spender)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", a.Name, err)
+		}
+		a.Granted = v.(bool)
+	}
+	return approvals, nil
+}
+
+// grantCall encodes the call granting an approval without limit
+func (s *Settler) grantCall(a *Approval) ([]byte, error) {
+	if a.isCollateral(s.Addresses) {
+		return erc20ABI.Pack("approve", a.Spender, math.MaxBig256)
+	}
+	return erc1155ABI.Pack("setApprovalForAll", a.Spender, true)
+}
+
+// Approve grants the missing approvals from the funder, one transaction each, and
+// waits for them to be mined. It returns the approvals it granted.
+func (s *Settler) Approve(ctx context.Context) ([]Approval, error) {
+	approvals, err := s.Approvals(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var sent []*types.Transaction
+	var granted []Approval
+	for _, a := range approvals {
+		if a.Granted {
+			continue
+		}
+		data, err := s.grantCall(&a)
+		if err != nil {
+			return nil, err
+		}
+		tx, err := s.send(ctx, a.Token, data)
+		if err != nil {
+			return granted, fmt.Errorf("approving %s: %w", a.Name, err)
+		}
+		log.Printf("Approval %s sent: %s", a.Name, tx.Hash().Hex())
+		sent = append(sent, tx)
+		a.Granted = true
+		granted = append(granted, a)
+	}
+
+	for i, tx := range sent {
+		if _, err := s.Wait(ctx, tx); err != nil {
+			return granted[:i], fmt.Errorf("approving %s: %w", granted[i].Name, err)
+		}
+	}
+	return granted, nil
+}
@@ -0,0 +1,112 @@
+package settlement
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+
+	"poly/pkg/signer"
+)
+
+// mineEvery commits blocks in the background until the test ends
+func mineEvery(t *testing.T, chain *simulated.Backend, d time.Duration) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(d):
+				chain.Commit()
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		close(done)
+		<-stopped
+	})
+}
+
+func TestApprovals(t *testing.T) {
+	ctx := context.Background()
+	key := newTestKey(t)
+	chain := newTestChain(t, key, map[common.Address][]byte{
+		PolygonAddresses.Collateral:        recorderCode,
+		PolygonAddresses.ConditionalTokens: recorderCode,
+	})
+	s := NewSettler(chain.Client(), signer.NewKeySigner(key))
+	s.PollInterval = 10 * time.Millisecond
+	a := PolygonAddresses
+
+	// Granted already: unlimited USDC for the CTF exchange, tokens for the adapter.
+	// A finite allowance does not count.
+	call, _ := erc20ABI.Pack("allowance", s.Funder, a.CTFExchange)
+	program(t, chain, key, a.Collateral, call, math.MaxBig256)
+	call, _ = erc20ABI.Pack("allowance", s.Funder, a.NegRiskExchange)
+	program(t, chain, key, a.Collateral, call, big.NewInt(1000e6))
+	call, _ = erc1155ABI.Pack("isApprovedForAll", s.Funder, a.NegRiskAdapter)
+	program(t, chain, key, a.ConditionalTokens, call, big.NewInt(1))
+
+	approvals, err := s.Approvals(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(approvals) != 6 {
+		t.Fatalf("expected 6 approvals, got %d", len(approvals))
+	}
+	var granted int
+	for _, ap := range approvals {
+		if ap.Granted {
+			granted++
+		}
+	}
+	if granted != 2 {
+		t.Errorf("expected 2 approvals granted, got %+v", approvals)
+	}
+
+	// The four missing ones are sent and mined
+	mineEvery(t, chain, 20*time.Millisecond)
+	start, _ := chain.Client().BlockNumber(ctx)
+	done, err := s.Approve(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 4 {
+		t.Errorf("expected 4 approvals granted, got %+v", done)
+	}
+
+	logs, err := chain.Client().FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(start),
+		Addresses: []common.Address{a.Collateral, a.ConditionalTokens},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Spender by spender, USDC first
+	approve := func(spender common.Address) []byte {
+		data, _ := erc20ABI.Pack("approve", spender, math.MaxBig256)
+		return data
+	}
+	operator := func(operator common.Address) []byte {
+		data, _ := erc1155ABI.Pack("setApprovalForAll", operator, true)
+		return data
+	}
+	want := [][]byte{operator(a.CTFExchange), approve(a.NegRiskExchange), operator(a.NegRiskExchange), approve(a.NegRiskAdapter)}
+	if len(logs) != len(want) {
+		t.Fatalf("expected %d approval calls, got %d", len(want), len(logs))
+	}
+	for i, l := range logs {
+		if !bytes.Equal(l.Data, want[i]) {
+			t.Errorf("call %d = %x, want %x", i, l.Data, want[i])
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package settlement
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"poly/pkg/exchange"
+)
+
+// binaryPartition splits a binary condition into its two outcomes (index sets 0b01 and 0b10)
+var binaryPartition = []*big.Int{big.NewInt(1), big.NewInt(2)}
+
+// mergeCall encodes the merge of amount pairs of a condition; neg-risk markets
+// merge through the adapter, which holds their collateral
+func (s *Settler) mergeCall(conditionID common.Hash, amount exchange.Amount, negRisk bool) (common.Address, []byte, error) {
+	if negRisk {
+		data, err := negRiskAdapterABI.Pack("mergePositions", conditionID, amount.Raw())
+		return s.Addresses.NegRiskAdapter, data, err
+	}
+	data, err := ctfABI.Pack("mergePositions", s.Addresses.Collateral, common.Hash{}, conditionID, binaryPartition, amount.Raw())
+	return s.Addresses.ConditionalTokens, data, err
+}
+
+// SendMerge submits the merge of amount complete pairs of a market back into
+// collateral, without waiting for it to be mined
+func (s *Settler) SendMerge(ctx context.Context, marketID string, amount exchange.Amount, negRisk bool) (*types.Transaction, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("nothing to merge: %s", amount)
+	}
+	to, data, err := s.mergeCall(common.HexToHash(marketID), amount, negRisk)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := s.send(ctx, to, data)
+	if err != nil {
+		return nil, fmt.Errorf("merging %s pairs of %s: %w", amount, marketID, err)
+	}
+	return tx, nil
+}
+
+// MergePositions burns amount shares of each outcome of a market (the condition ID)
+// and returns as much collateral to the funder, waiting for the receipt
+func (s *Settler) MergePositions(ctx context.Context, marketID string, amount exchange.Amount, negRisk bool) (*types.Receipt, error) {
+	tx, err := s.SendMerge(ctx, marketID, amount, negRisk)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Merge of %s pairs of %s sent: %s (gas limit %d)", amount, marketID, tx.Hash().Hex(), tx.Gas())
+
+	receipt, err := s.Wait(ctx, tx)
+	if err != nil {
+		return receipt, err
+	}
+	log.Printf("Merge %s mined in block %s, gas used %d", tx.Hash().Hex(), receipt.BlockNumber, receipt.GasUsed)
+	return receipt, nil
+}
@@ -0,0 +1,67 @@
+package settlement
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var exchangeABI = mustABI(`[
+	{"name":"nonces","type":"function","stateMutability":"view",
+	"inputs":[{"name":"","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"name":"incrementNonce","type":"function","stateMutability":"nonpayable","inputs":[],"outputs":[]}]`)
+
+// Exchanges returns the contracts orders are signed against; each keeps its own
+// nonce per maker
+func (s *Settler) Exchanges() []common.Address {
+	return []common.Address{s.Addresses.CTFExchange, s.Addresses.NegRiskExchange}
+}
+
+// ExchangeNonce returns the funder's current order nonce on an exchange contract.
+// Only orders signed with it can be filled there.
+func (s *Settler) ExchangeNonce(ctx context.Context, exchangeAddr common.Address) (*big.Int, error) {
+	v, err := s.view(ctx, exchangeABI, exchangeAddr, "nonces", s.Funder)
+	if err != nil {
+		return nil, err
+	}
+	return v.(*big.Int), nil
+}
+
+// IncrementNonce invalidates every order the funder signed so far by incrementing
+// its nonce on both exchanges, and waits for the transactions to be mined. It
+// returns the new nonce of each exchange.
+func (s *Settler) IncrementNonce(ctx context.Context) (map[common.Address]*big.Int, error) {
+	data, err := exchangeABI.Pack("incrementNonce")
+	if err != nil {
+		return nil, err
+	}
+
+	var sent []*types.Transaction
+	for _, exchangeAddr := range s.Exchanges() {
+		tx, err := s.send(ctx, exchangeAddr, data)
+		if err != nil {
+			return nil, fmt.Errorf("incrementing nonce on %s: %w", exchangeAddr.Hex(), err)
+		}
+		log.Printf("Nonce increment on %s sent: %s", exchangeAddr.Hex(), tx.Hash().Hex())
+		sent = append(sent, tx)
+	}
+	for _, tx := range sent {
+		if _, err := s.Wait(ctx, tx); err != nil {
+			return nil, fmt.Errorf("incrementing nonce: %w", err)
+		}
+	}
+
+	nonces := make(map[common.Address]*big.Int)
+	for _, exchangeAddr := range s.Exchanges() {
+		n, err := s.ExchangeNonce(ctx, exchangeAddr)
+		if err != nil {
+			return nil, err
+		}
+		nonces[exchangeAddr] = n
+	}
+	return nonces, nil
+}
@@ -0,0 +1,65 @@
+package settlement
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+
+	"poly/pkg/signer"
+)
+
+func TestIncrementNonce(t *testing.T) {
+	ctx := context.Background()
+	key := newTestKey(t)
+	chain := newTestChain(t, key, map[common.Address][]byte{
+		PolygonAddresses.CTFExchange:     recorderCode,
+		PolygonAddresses.NegRiskExchange: recorderCode,
+	})
+	s := NewSettler(chain.Client(), signer.NewKeySigner(key))
+	s.PollInterval = 10 * time.Millisecond
+	a := PolygonAddresses
+
+	// The recorder answers nonces(funder) with what it was programmed to
+	call, _ := exchangeABI.Pack("nonces", s.Funder)
+	program(t, chain, key, a.NegRiskExchange, call, big.NewInt(3))
+
+	if n, err := s.ExchangeNonce(ctx, a.CTFExchange); err != nil || n.Sign() != 0 {
+		t.Errorf("CTF Exchange nonce = %v (err %v), want 0", n, err)
+	}
+	if n, err := s.ExchangeNonce(ctx, a.NegRiskExchange); err != nil || n.Cmp(big.NewInt(3)) != 0 {
+		t.Errorf("Neg Risk CTF Exchange nonce = %v (err %v), want 3", n, err)
+	}
+
+	// incrementNonce() is sent to both exchanges
+	mineEvery(t, chain, 20*time.Millisecond)
+	start, _ := chain.Client().BlockNumber(ctx)
+	nonces, err := s.IncrementNonce(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nonces) != 2 || nonces[a.NegRiskExchange].Cmp(big.NewInt(3)) != 0 {
+		t.Errorf("unexpected nonces %v", nonces)
+	}
+
+	logs, err := chain.Client().FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(start),
+		Addresses: []common.Address{a.CTFExchange, a.NegRiskExchange},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := exchangeABI.Pack("incrementNonce")
+	if len(logs) != 2 {
+		t.Fatalf("expected 2 increments, got %d", len(logs))
+	}
+	for i, l := range logs {
+		if l.Address != s.Exchanges()[i] || !bytes.Equal(l.Data, want) {
+			t.Errorf("call %d to %s = %x, want %x", i, l.Address.Hex(), l.Data, want)
+		}
+	}
+}
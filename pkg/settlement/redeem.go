@@ -0,0 +1,106 @@
+package settlement
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"poly/pkg/exchange"
+)
+
+// ErrUnresolved is returned for conditions the oracle has not reported on yet
+var ErrUnresolved = errors.New("condition not resolved")
+
+// Resolution is the collateral one share of each outcome redeems for, e.g. 1 and 0
+type Resolution struct {
+	Up   exchange.Amount
+	Down exchange.Amount
+}
+
+// Price returns the payout of one share of side, so a Resolution can mark a portfolio
+func (r *Resolution) Price(side exchange.Side) exchange.Amount {
+	if side == exchange.SideDown {
+		return r.Down
+	}
+	return r.Up
+}
+
+// Resolution reads the payout vector the oracle reported for a market (the condition ID).
+// The CTF holds it for neg-risk markets too, as the adapter reports to it.
+func (s *Settler) Resolution(ctx context.Context, marketID string) (*Resolution, error) {
+	conditionID := common.HexToHash(marketID)
+	v, err := s.view(ctx, ctfABI, s.Addresses.ConditionalTokens, "payoutDenominator", conditionID)
+	if err != nil {
+		return nil, err
+	}
+	den := v.(*big.Int)
+	if den.Sign() == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnresolved, marketID)
+	}
+
+	var payouts [2]exchange.Amount
+	for i := range payouts {
+		v, err := s.view(ctx, ctfABI, s.Addresses.ConditionalTokens, "payoutNumerators", conditionID, big.NewInt(int64(i)))
+		if err != nil {
+			return nil, err
+		}
+		// Outcome index 0 is UP/YES, the first token of the market
+		num := v.(*big.Int)
+		if num.Sign() < 0 || num.Cmp(den) > 0 {
+			return nil, fmt.Errorf("payout %s/%s of outcome %d of %s is out of range", num, den, i, marketID)
+		}
+		payout := new(big.Int).Mul(num, big.NewInt(1_000_000))
+		payouts[i] = exchange.Amount(payout.Quo(payout, den).Int64())
+	}
+	return &Resolution{Up: payouts[0], Down: payouts[1]}, nil
+}
+
+// redeemCall encodes the redemption of a resolved condition. The CTF redeems the
+// funder's whole balance of both outcomes; the neg-risk adapter takes the amounts.
+func (s *Settler) redeemCall(conditionID common.Hash, up, down exchange.Amount, negRisk bool) (common.Address, []byte, error) {
+	if negRisk {
+		data, err := negRiskAdapterABI.Pack("redeemPositions", conditionID, []*big.Int{up.Raw(), down.Raw()})
+		return s.Addresses.NegRiskAdapter, data, err
+	}
+	data, err := ctfABI.Pack("redeemPositions", s.Addresses.Collateral, common.Hash{}, conditionID, binaryPartition)
+	return s.Addresses.ConditionalTokens, data, err
+}
+
+// SendRedeem submits the redemption of the funder's shares of a resolved market,
+// without waiting for it to be mined
+func (s *Settler) SendRedeem(ctx context.Context, marketID string, up, down exchange.Amount, negRisk bool) (*types.Transaction, error) {
+	if up <= 0 && down <= 0 {
+		return nil, fmt.Errorf("nothing to redeem in %s", marketID)
+	}
+	to, data, err := s.redeemCall(common.HexToHash(marketID), up, down, negRisk)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := s.send(ctx, to, data)
+	if err != nil {
+		return nil, fmt.Errorf("redeeming %s: %w", marketID, err)
+	}
+	return tx, nil
+}
+
+// RedeemPositions turns the funder's up and down shares of a resolved market into
+// collateral at the reported payouts, waiting for the receipt
+func (s *Settler) RedeemPositions(ctx context.Context, marketID string, up, down exchange.Amount, negRisk bool) (*types.Receipt, error) {
+	tx, err := s.SendRedeem(ctx, marketID, up, down, negRisk)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Redemption of %s UP and %s DOWN shares of %s sent: %s (gas limit %d)", up, down, marketID, tx.Hash().Hex(), tx.Gas())
+
+	receipt, err := s.Wait(ctx, tx)
+	if err != nil {
+		return receipt, err
+	}
+	log.Printf("Redemption %s mined in block %s, gas used %d", tx.Hash().Hex(), receipt.BlockNumber, receipt.GasUsed)
+	return receipt, nil
+}
@@ -0,0 +1,104 @@
+package settlement
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+
+	"poly/pkg/exchange"
+	"poly/pkg/signer"
+)
+
+// resolve plays the oracle: it programs the CTF stand-in to report payout numerators
+// for the condition, as reportPayouts would
+func resolve(t *testing.T, chain *simulated.Backend, key *ecdsa.PrivateKey, condition string, numerators ...int64) {
+	t.Helper()
+	var den int64
+	for i, num := range numerators {
+		call, _ := ctfABI.Pack("payoutNumerators", common.HexToHash(condition), big.NewInt(int64(i)))
+		program(t, chain, key, PolygonAddresses.ConditionalTokens, call, big.NewInt(num))
+		den += num
+	}
+	call, _ := ctfABI.Pack("payoutDenominator", common.HexToHash(condition))
+	program(t, chain, key, PolygonAddresses.ConditionalTokens, call, big.NewInt(den))
+}
+
+func TestResolution(t *testing.T) {
+	ctx := context.Background()
+	key := newTestKey(t)
+	chain := newTestChain(t, key, map[common.Address][]byte{PolygonAddresses.ConditionalTokens: recorderCode})
+	s := NewSettler(chain.Client(), signer.NewKeySigner(key))
+
+	if _, err := s.Resolution(ctx, testCondition); !errors.Is(err, ErrUnresolved) {
+		t.Errorf("expected ErrUnresolved before the oracle reports, got %v", err)
+	}
+
+	resolve(t, chain, key, testCondition, 0, 1)
+	r, err := s.Resolution(ctx, testCondition)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Up != 0 || r.Down != exchange.AmountFromFloat(1) || r.Price(exchange.SideDown) != r.Down {
+		t.Errorf("expected DOWN to win, got %+v", r)
+	}
+
+	// A split payout, e.g. a market resolved 50-50
+	other := "0x0000000000000000000000000000000000000000000000000000000000000a0b"
+	resolve(t, chain, key, other, 1, 1)
+	if r, _ := s.Resolution(ctx, other); r == nil || r.Up != exchange.AmountFromFloat(0.5) || r.Down != r.Up {
+		t.Errorf("expected a 50-50 payout, got %+v", r)
+	}
+
+	// A numerator above the denominator is not a payout, whatever its size
+	bogus := "0x0000000000000000000000000000000000000000000000000000000000000a0c"
+	resolve(t, chain, key, bogus, 1, 1)
+	call, _ := ctfABI.Pack("payoutNumerators", common.HexToHash(bogus), big.NewInt(0))
+	program(t, chain, key, PolygonAddresses.ConditionalTokens, call, new(big.Int).Lsh(big.NewInt(1), 100))
+	if r, err := s.Resolution(ctx, bogus); err == nil {
+		t.Errorf("expected an out of range payout to fail, got %+v", r)
+	}
+}
+
+func TestRedeemPositions(t *testing.T) {
+	ctx := context.Background()
+	key := newTestKey(t)
+	chain := newTestChain(t, key, map[common.Address][]byte{
+		PolygonAddresses.ConditionalTokens: recorderCode,
+		PolygonAddresses.NegRiskAdapter:    recorderCode,
+	})
+	s := NewSettler(chain.Client(), signer.NewKeySigner(key))
+	up, down := exchange.AmountFromFloat(20), exchange.AmountFromFloat(5)
+
+	redeem := func(negRisk bool) *types.Receipt {
+		tx, err := s.SendRedeem(ctx, testCondition, up, down, negRisk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		chain.Commit()
+		receipt, err := s.Wait(ctx, tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return receipt
+	}
+
+	// The CTF redeems both index sets of the whole balance
+	want, _ := ctfABI.Pack("redeemPositions", PolygonAddresses.Collateral, common.Hash{},
+		common.HexToHash(testCondition), []*big.Int{big.NewInt(1), big.NewInt(2)})
+	calledWith(t, redeem(false), PolygonAddresses.ConditionalTokens, want)
+
+	// The adapter takes the amounts of each outcome
+	want, _ = negRiskAdapterABI.Pack("redeemPositions", common.HexToHash(testCondition),
+		[]*big.Int{big.NewInt(20_000_000), big.NewInt(5_000_000)})
+	calledWith(t, redeem(true), PolygonAddresses.NegRiskAdapter, want)
+
+	if _, err := s.SendRedeem(ctx, testCondition, 0, 0, false); err == nil {
+		t.Error("expected an empty redemption to fail")
+	}
+}
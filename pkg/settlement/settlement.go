@@ -0,0 +1,311 @@
+package settlement
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"poly/pkg/exchange"
+	"poly/pkg/signer"
+)
+
+// Addresses are the Polygon contracts positions settle through
+type Addresses struct {
+	Collateral        common.Address // USDC.e
+	ConditionalTokens common.Address
+	NegRiskAdapter    common.Address
+	ProxyFactory      common.Address // Polymarket proxy wallet factory
+	CTFExchange       common.Address
+	NegRiskExchange   common.Address
+}
+
+// PolygonAddresses are the mainnet deployments
+var PolygonAddresses = Addresses{
+	Collateral:        common.HexToAddress("0x2791Bca1f2de4661ED88A30C99A7a9449Aa84174"),
+	ConditionalTokens: common.HexToAddress("0x4D97DCd97eC945f40cF65F87097ACe5EA0476045"),
+	NegRiskAdapter:    common.HexToAddress("0xd91E80cF2E7be2e162c6513ceD06f1dD0dA35296"),
+	ProxyFactory:      common.HexToAddress(exchange.ProxyFactoryAddress),
+	CTFExchange:       common.HexToAddress(exchange.CTFExchangeAddress),
+	NegRiskExchange:   common.HexToAddress(exchange.NegRiskCTFExchangeAddress),
+}
+
+// Backend is the chain access the settler needs, e.g. an ethclient.Client
+type Backend interface {
+	ChainID(ctx context.Context) (*big.Int, error)
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+	CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error)
+}
+
+var (
+	// ErrReverted is returned for transactions mined with a failed status
+	ErrReverted = errors.New("transaction reverted")
+
+	// ErrPending is returned for transactions not mined yet
+	ErrPending = errors.New("transaction not mined yet")
+
+	// ErrNotDeployed is returned for a Safe funder without code: a call to it would
+	// succeed without doing anything
+	ErrNotDeployed = errors.New("funder wallet not deployed")
+)
+
+// gasMargin pads gas estimates (percent), as state may change before inclusion
+const gasMargin = 20
+
+// Settler sends the funder's on-chain settlement transactions. The signer signs
+// them; when the funder is a proxy wallet or Safe, calls are routed through it.
+type Settler struct {
+	Backend       Backend
+	Addresses     Addresses
+	Funder        common.Address
+	SignatureType exchange.SignatureType // How the signer controls the funder, as for orders
+	Signer        signer.Signer
+	PollInterval  time.Duration // How often Wait checks whether a transaction was mined
+}
+
+// NewSettler creates a settler for an EOA funder on Polygon; set Funder and
+// SignatureType to settle from a proxy wallet or Safe instead
+func NewSettler(backend Backend, s signer.Signer) *Settler {
+	return &Settler{
+		Backend:       backend,
+		Addresses:     PolygonAddresses,
+		Funder:        s.Address(),
+		SignatureType: exchange.SignatureEOA,
+		Signer:        s,
+		PollInterval:  time.Second,
+	}
+}
+
+// from returns the address sending the transactions
+func (s *Settler) from() common.Address {
+	return s.Signer.Address()
+}
+
+func mustABI(def string) abi.ABI {
+	a, err := abi.JSON(strings.NewReader(def))
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+var (
+	ctfABI = mustABI(`[
+		{"name":"mergePositions","type":"function","stateMutability":"nonpayable","outputs":[],
+		"inputs":[{"name":"collateralToken","type":"address"},{"name":"parentCollectionId","type":"bytes32"},
+			{"name":"conditionId","type":"bytes32"},{"name":"partition","type":"uint256[]"},{"name":"amount","type":"uint256"}]},
+		{"name":"redeemPositions","type":"function","stateMutability":"nonpayable","outputs":[],
+		"inputs":[{"name":"collateralToken","type":"address"},{"name":"parentCollectionId","type":"bytes32"},
+			{"name":"conditionId","type":"bytes32"},{"name":"indexSets","type":"uint256[]"}]},
+		{"name":"payoutDenominator","type":"function","stateMutability":"view",
+		"inputs":[{"name":"conditionId","type":"bytes32"}],"outputs":[{"name":"","type":"uint256"}]},
+		{"name":"payoutNumerators","type":"function","stateMutability":"view",
+		"inputs":[{"name":"conditionId","type":"bytes32"},{"name":"index","type":"uint256"}],"outputs":[{"name":"","type":"uint256"}]}]`)
+
+	negRiskAdapterABI = mustABI(`[
+		{"name":"mergePositions","type":"function","stateMutability":"nonpayable","outputs":[],
+		"inputs":[{"name":"_conditionId","type":"bytes32"},{"name":"_amount","type":"uint256"}]},
+		{"name":"redeemPositions","type":"function","stateMutability":"nonpayable","outputs":[],
+		"inputs":[{"name":"_conditionId","type":"bytes32"},{"name":"_amounts","type":"uint256[]"}]}]`)
+
+	proxyFactoryABI = mustABI(`[{"name":"proxy","type":"function","stateMutability":"payable",
+		"inputs":[{"name":"calls","type":"tuple[]","components":[
+			{"name":"typeCode","type":"uint8"},{"name":"to","type":"address"},
+			{"name":"value","type":"uint256"},{"name":"data","type":"bytes"}]}],
+		"outputs":[{"name":"returnValues","type":"bytes[]"}]}]`)
+
+	safeABI = mustABI(`[{"name":"execTransaction","type":"function","stateMutability":"payable",
+		"inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"},{"name":"data","type":"bytes"},
+			{"name":"operation","type":"uint8"},{"name":"safeTxGas","type":"uint256"},{"name":"baseGas","type":"uint256"},
+			{"name":"gasPrice","type":"uint256"},{"name":"gasToken","type":"address"},
+			{"name":"refundReceiver","type":"address"},{"name":"signatures","type":"bytes"}],
+		"outputs":[{"name":"success","type":"bool"}]}]`)
+)
+
+// proxyCall is a call forwarded by the proxy wallet factory
+type proxyCall struct {
+	TypeCode uint8
+	To       common.Address
+	Value    *big.Int
+	Data     []byte
+}
+
+const proxyCallTypeCall = 1
+
+// route wraps a call the funder makes into the transaction the key sends
+func (s *Settler) route(to common.Address, data []byte) (common.Address, []byte, error) {
+	switch s.SignatureType {
+	case exchange.SignatureEOA:
+		if s.Funder != s.from() {
+			return common.Address{}, nil, fmt.Errorf("funder %s differs from signer %s", s.Funder.Hex(), s.from().Hex())
+		}
+		return to, data, nil
+
+	case exchange.SignaturePolyProxy:
+		// The factory forwards to the proxy wallet of msg.sender
+		wrapped, err := proxyFactoryABI.Pack("proxy", []proxyCall{{proxyCallTypeCall, to, big.NewInt(0), data}})
+		return s.Addresses.ProxyFactory, wrapped, err
+
+	case exchange.SignatureGnosisSafe:
+		// A 1-of-1 Safe accepts its owner as the sender in place of a signature:
+		// r = owner, s = 0, v = 1 ("approved hash")
+		sig := make([]byte, 65)
+		copy(sig[12:32], s.from().Bytes())
+		sig[64] = 1
+		wrapped, err := safeABI.Pack("execTransaction", to, big.NewInt(0), data, uint8(0),
+			big.NewInt(0), big.NewInt(0), big.NewInt(0), common.Address{}, common.Address{}, sig)
+		return s.Funder, wrapped, err
+	}
+	return common.Address{}, nil, fmt.Errorf("unsupported signature type %d", s.SignatureType)
+}
+
+// view runs a view function returning a single value
+func (s *Settler) view(ctx context.Context, contract abi.ABI, to common.Address, method string, args ...interface{}) (interface{}, error) {
+	data, err := contract.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	out, err := s.Backend.CallContract(ctx, ethereum.CallMsg{To: &to, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("calling %s: %w", method, err)
+	}
+	values, err := contract.Unpack(method, out)
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", method, err)
+	}
+	return values[0], nil
+}
+
+// send routes a call of the funder, estimates its gas and submits it
+func (s *Settler) send(ctx context.Context, to common.Address, data []byte) (*types.Transaction, error) {
+	to, data, err := s.route(to, data)
+	if err != nil {
+		return nil, err
+	}
+	from := s.from()
+
+	// The proxy factory deploys the proxy wallet on first use; a Safe must exist
+	if s.SignatureType == exchange.SignatureGnosisSafe {
+		code, err := s.Backend.CodeAt(ctx, s.Funder, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(code) == 0 {
+			return nil, fmt.Errorf("%w: Safe %s has no code, log in to Polymarket once to create it", ErrNotDeployed, s.Funder.Hex())
+		}
+	}
+
+	gas, err := s.Backend.EstimateGas(ctx, ethereum.CallMsg{From: from, To: &to, Data: data})
+	if err != nil {
+		return nil, fmt.Errorf("estimating gas: %w", err)
+	}
+	gas += gas * gasMargin / 100
+
+	chainID, err := s.Backend.ChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := s.Backend.PendingNonceAt(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	txData, err := s.fees(ctx, chainID, nonce, gas, to, data)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.Signer.SignTx(ctx, types.NewTx(txData), chainID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Backend.SendTransaction(ctx, tx); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// fees prices a transaction: EIP-1559 fees when the chain has a base fee, a
+// legacy gas price on pre-London chains or RPCs whose headers lack one
+func (s *Settler) fees(ctx context.Context, chainID *big.Int, nonce, gas uint64, to common.Address, data []byte) (types.TxData, error) {
+	head, err := s.Backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if head.BaseFee == nil {
+		price, err := s.Backend.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &types.LegacyTx{Nonce: nonce, GasPrice: price, Gas: gas, To: &to, Data: data}, nil
+	}
+
+	tip, err := s.Backend.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Leave room for the base fee to double before inclusion
+	feeCap := new(big.Int).Add(tip, new(big.Int).Mul(head.BaseFee, big.NewInt(2)))
+	return &types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     nonce,
+		GasTipCap: tip,
+		GasFeeCap: feeCap,
+		Gas:       gas,
+		To:        &to,
+		Data:      data,
+	}, nil
+}
+
+// Receipt returns the receipt of a sent transaction without waiting: ErrPending
+// while it is not mined, and the receipt along with ErrReverted when it failed
+func (s *Settler) Receipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	receipt, err := s.Backend.TransactionReceipt(ctx, txHash)
+	if err != nil {
+		if receiptPending(err) {
+			return nil, fmt.Errorf("%w: %s", ErrPending, txHash.Hex())
+		}
+		return nil, err
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return receipt, fmt.Errorf("%w: %s", ErrReverted, txHash.Hex())
+	}
+	return receipt, nil
+}
+
+// Wait blocks until tx is mined, returning its receipt; a reverted transaction
+// returns the receipt along with ErrReverted
+func (s *Settler) Wait(ctx context.Context, tx *types.Transaction) (*types.Receipt, error) {
+	for {
+		receipt, err := s.Receipt(ctx, tx.Hash())
+		if !errors.Is(err, ErrPending) {
+			return receipt, err
+		}
+		t := time.NewTimer(s.PollInterval)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// receiptPending reports whether a receipt lookup failed only because the
+// transaction is not mined, or not indexed, yet
+func receiptPending(err error) bool {
+	return errors.Is(err, ethereum.NotFound) || strings.Contains(err.Error(), "indexing is in progress")
+}
@@ -0,0 +1,281 @@
+package settlement
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+
+	"poly/pkg/exchange"
+	"poly/pkg/signer"
+)
+
+// recorderCode is the runtime code of a stand-in contract. It logs the calldata of
+// every call (LOG0) and returns the word stored under keccak256(calldata), so tests
+// can both inspect calls and program replies. A 64-byte call (key, value) stores
+// value under key instead.
+//
+//	CALLDATASIZE PUSH1 0x40 EQ PUSH1 0x18 JUMPI
+//	CALLDATASIZE PUSH0 PUSH0 CALLDATACOPY CALLDATASIZE PUSH0 LOG0
+//	CALLDATASIZE PUSH0 KECCAK256 SLOAD PUSH0 MSTORE PUSH1 0x20 PUSH0 RETURN
+//	JUMPDEST PUSH1 0x20 CALLDATALOAD PUSH0 CALLDATALOAD SSTORE STOP
+var recorderCode = hexutil.MustDecode("0x36604014601857365f5f37365fa0365f20545f5260205ff35b6020355f355500")
+
+// revertCode rejects every call
+var revertCode = hexutil.MustDecode("0x5f5ffd")
+
+const testCondition = "0x5eed5eed5eed5eed5eed5eed5eed5eed5eed5eed5eed5eed5eed5eed5eed5eed"
+
+// newTestChain starts a simulated chain where key holds gas money and the given
+// contracts are deployed
+func newTestChain(t *testing.T, key *ecdsa.PrivateKey, code map[common.Address][]byte) *simulated.Backend {
+	t.Helper()
+	alloc := types.GenesisAlloc{
+		crypto.PubkeyToAddress(key.PublicKey): {Balance: new(big.Int).Mul(big.NewInt(1e18), big.NewInt(100))},
+	}
+	for addr, c := range code {
+		alloc[addr] = types.Account{Code: c, Balance: big.NewInt(0)}
+	}
+	chain := simulated.NewBackend(alloc)
+	t.Cleanup(func() { chain.Close() })
+	return chain
+}
+
+func newTestKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// program makes a stand-in contract answer call with value, by storing value under
+// keccak256(call) with a 64-byte call, and mines it
+func program(t *testing.T, chain *simulated.Backend, key *ecdsa.PrivateKey, contract common.Address, call []byte, value *big.Int) {
+	t.Helper()
+	ctx := context.Background()
+	client := chain.Client()
+	chainID, _ := client.ChainID(ctx)
+	nonce, err := client.PendingNonceAt(ctx, crypto.PubkeyToAddress(key.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := append(crypto.Keccak256(call), common.BigToHash(value).Bytes()...)
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(chainID), &types.DynamicFeeTx{
+		ChainID: chainID, Nonce: nonce, GasTipCap: big.NewInt(1e9), GasFeeCap: big.NewInt(1e11),
+		Gas: 100_000, To: &contract, Data: data,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SendTransaction(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+	chain.Commit()
+}
+
+// mined sends a merge, mines it and returns its receipt
+func mined(t *testing.T, chain *simulated.Backend, s *Settler, negRisk bool) *types.Receipt {
+	t.Helper()
+	ctx := context.Background()
+	tx, err := s.SendMerge(ctx, testCondition, exchange.AmountFromFloat(20), negRisk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain.Commit()
+	receipt, err := s.Wait(ctx, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.GasUsed == 0 || receipt.GasUsed > tx.Gas() {
+		t.Errorf("gas used %d outside the limit %d", receipt.GasUsed, tx.Gas())
+	}
+	return receipt
+}
+
+// calledWith checks that the receipt recorded one call to the contract with data
+func calledWith(t *testing.T, receipt *types.Receipt, contract common.Address, data []byte) {
+	t.Helper()
+	if len(receipt.Logs) != 1 {
+		t.Fatalf("expected one recorded call, got %d", len(receipt.Logs))
+	}
+	l := receipt.Logs[0]
+	if l.Address != contract {
+		t.Errorf("called %s, want %s", l.Address.Hex(), contract.Hex())
+	}
+	if !bytes.Equal(l.Data, data) {
+		t.Errorf("calldata\n%x\nwant\n%x", l.Data, data)
+	}
+}
+
+func TestMergePositions(t *testing.T) {
+	key := newTestKey(t)
+	chain := newTestChain(t, key, map[common.Address][]byte{
+		PolygonAddresses.ConditionalTokens: recorderCode,
+		PolygonAddresses.NegRiskAdapter:    recorderCode,
+	})
+	s := NewSettler(chain.Client(), signer.NewKeySigner(key))
+
+	// mergePositions(USDC, 0x0, condition, [1, 2], 20e6) on the CTF
+	want, _ := ctfABI.Pack("mergePositions", PolygonAddresses.Collateral, common.Hash{},
+		common.HexToHash(testCondition), []*big.Int{big.NewInt(1), big.NewInt(2)}, big.NewInt(20_000_000))
+	calledWith(t, mined(t, chain, s, false), PolygonAddresses.ConditionalTokens, want)
+
+	// mergePositions(condition, 20e6) on the neg-risk adapter
+	want, _ = negRiskAdapterABI.Pack("mergePositions", common.HexToHash(testCondition), big.NewInt(20_000_000))
+	calledWith(t, mined(t, chain, s, true), PolygonAddresses.NegRiskAdapter, want)
+}
+
+func TestMergeThroughProxyAndSafe(t *testing.T) {
+	key := newTestKey(t)
+	safe := common.HexToAddress("0x5afe00000000000000000000000000000000cafe")
+	chain := newTestChain(t, key, map[common.Address][]byte{
+		PolygonAddresses.ProxyFactory: recorderCode,
+		safe:                          recorderCode,
+	})
+	merge, _ := ctfABI.Pack("mergePositions", PolygonAddresses.Collateral, common.Hash{},
+		common.HexToHash(testCondition), []*big.Int{big.NewInt(1), big.NewInt(2)}, big.NewInt(20_000_000))
+
+	// The proxy factory forwards a CALL to the CTF from the sender's proxy wallet
+	s := NewSettler(chain.Client(), signer.NewKeySigner(key))
+	s.Funder = common.HexToAddress("0x9999999999999999999999999999999999999999")
+	s.SignatureType = exchange.SignaturePolyProxy
+	want, _ := proxyFactoryABI.Pack("proxy", []proxyCall{{1, PolygonAddresses.ConditionalTokens, big.NewInt(0), merge}})
+	calledWith(t, mined(t, chain, s, false), PolygonAddresses.ProxyFactory, want)
+
+	// The Safe executes the merge with its owner's pre-approved signature
+	s.Funder = safe
+	s.SignatureType = exchange.SignatureGnosisSafe
+	receipt := mined(t, chain, s, false)
+	args, err := safeABI.Methods["execTransaction"].Inputs.Unpack(receipt.Logs[0].Data[4:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args[0].(common.Address) != PolygonAddresses.ConditionalTokens || !bytes.Equal(args[2].([]byte), merge) {
+		t.Errorf("Safe asked to call %s with %x", args[0].(common.Address).Hex(), args[2])
+	}
+	sig := args[9].([]byte)
+	if common.BytesToAddress(sig[:32]) != crypto.PubkeyToAddress(key.PublicKey) || sig[64] != 1 {
+		t.Errorf("expected an approved-hash signature by the owner, got %x", sig)
+	}
+}
+
+// preLondon serves headers without a base fee, as pre-London chains and some RPCs do
+type preLondon struct {
+	simulated.Client
+}
+
+func (b preLondon) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	head, err := b.Client.HeaderByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	head = types.CopyHeader(head)
+	head.BaseFee = nil
+	return head, nil
+}
+
+func TestMergeWithoutBaseFee(t *testing.T) {
+	key := newTestKey(t)
+	chain := newTestChain(t, key, map[common.Address][]byte{PolygonAddresses.ConditionalTokens: recorderCode})
+	s := NewSettler(preLondon{chain.Client()}, signer.NewKeySigner(key))
+
+	// Priced with a legacy gas price instead
+	receipt := mined(t, chain, s, false)
+	if receipt.Type != types.LegacyTxType || receipt.Status != types.ReceiptStatusSuccessful {
+		t.Errorf("expected a successful legacy transaction, got type %d status %d", receipt.Type, receipt.Status)
+	}
+}
+
+func TestMergeErrors(t *testing.T) {
+	ctx := context.Background()
+	key := newTestKey(t)
+	chain := newTestChain(t, key, map[common.Address][]byte{PolygonAddresses.ConditionalTokens: revertCode})
+	s := NewSettler(chain.Client(), signer.NewKeySigner(key))
+
+	// A merge the contract rejects fails gas estimation instead of burning gas
+	if _, err := s.SendMerge(ctx, testCondition, exchange.AmountFromFloat(20), false); err == nil {
+		t.Error("expected the reverting merge to fail")
+	}
+	if _, err := s.SendMerge(ctx, testCondition, 0, false); err == nil {
+		t.Error("expected an empty merge to fail")
+	}
+
+	// An EOA route needs the key to be the funder
+	s.Funder = common.HexToAddress("0x9999999999999999999999999999999999999999")
+	if _, err := s.SendMerge(ctx, testCondition, exchange.AmountFromFloat(20), false); err == nil {
+		t.Error("expected a funder mismatch")
+	}
+
+	// A Safe without code would swallow the call
+	s.SignatureType = exchange.SignatureGnosisSafe
+	if _, err := s.SendMerge(ctx, testCondition, exchange.AmountFromFloat(20), false); !errors.Is(err, ErrNotDeployed) {
+		t.Errorf("expected ErrNotDeployed, got %v", err)
+	}
+}
+
+func TestMergeWaitsForReceipt(t *testing.T) {
+	key := newTestKey(t)
+	chain := newTestChain(t, key, map[common.Address][]byte{PolygonAddresses.ConditionalTokens: recorderCode})
+	s := NewSettler(chain.Client(), signer.NewKeySigner(key))
+	s.PollInterval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	done := make(chan error)
+	go func() {
+		_, err := s.MergePositions(ctx, testCondition, exchange.AmountFromFloat(5), false)
+		done <- err
+	}()
+
+	// Mine blocks until the merge lands
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatal(err)
+			}
+			return
+		case <-time.After(20 * time.Millisecond):
+			chain.Commit()
+		}
+	}
+}
+
+func TestWaitTimesOut(t *testing.T) {
+	key := newTestKey(t)
+	chain := newTestChain(t, key, map[common.Address][]byte{PolygonAddresses.ConditionalTokens: recorderCode})
+	s := NewSettler(chain.Client(), signer.NewKeySigner(key))
+	s.PollInterval = 10 * time.Millisecond
+
+	tx, err := s.SendMerge(context.Background(), testCondition, exchange.AmountFromFloat(5), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Never mined
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := s.Wait(ctx, tx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the wait to time out, got %v", err)
+	}
+
+	// Receipt reports the state without waiting
+	if _, err := s.Receipt(context.Background(), tx.Hash()); !errors.Is(err, ErrPending) {
+		t.Errorf("expected ErrPending before mining, got %v", err)
+	}
+	chain.Commit()
+	if receipt, err := s.Receipt(context.Background(), tx.Hash()); err != nil || receipt.TxHash != tx.Hash() {
+		t.Errorf("expected the receipt once mined, got %v", err)
+	}
+}
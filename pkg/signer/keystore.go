@@ -0,0 +1,22 @@
+package signer
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+)
+
+// FromKeystore decrypts an encrypted key file (Web3 Secret Storage, as written by
+// geth account new or clef) with its password
+func FromKeystore(path, password string) (*KeySigner, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := keystore.DecryptKey(data, password)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting %s: %w", path, err)
+	}
+	return NewKeySigner(key.PrivateKey), nil
+}
@@ -0,0 +1,35 @@
+package signer
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestFromKeystore(t *testing.T) {
+	key, _ := crypto.HexToECDSA(testPrivateKey[2:])
+	// Light scrypt parameters keep the test fast
+	ks := keystore.NewKeyStore(t.TempDir(), keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.ImportECDSA(key, "hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := account.URL.Path
+
+	s, err := FromKeystore(path, "hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Address() != testAddress {
+		t.Errorf("address = %s, want %s", s.Address().Hex(), testAddress.Hex())
+	}
+
+	if _, err := FromKeystore(path, "wrong"); err == nil {
+		t.Error("expected a wrong password to fail")
+	}
+	if _, err := FromKeystore(filepath.Join(t.TempDir(), "missing.json"), "hunter2"); err == nil {
+		t.Error("expected a missing file to fail")
+	}
+}
@@ -0,0 +1,96 @@
+package signer
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// DefaultPath is the first account of the standard Ethereum derivation (BIP-44), as used by most wallets
+const DefaultPath = "m/44'/60'/0'/0/0"
+
+// FromMnemonic derives the key of a BIP-39 mnemonic, with its optional passphrase,
+// at a BIP-32 derivation path, e.g. DefaultPath
+func FromMnemonic(mnemonic, passphrase, path string) (*KeySigner, error) {
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mnemonic: %w", err)
+	}
+	derivation, err := accounts.ParseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, chainCode, err := masterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	for _, index := range derivation {
+		if key, chainCode, err = childKey(key, chainCode, index); err != nil {
+			return nil, fmt.Errorf("deriving %s: %w", path, err)
+		}
+	}
+
+	pk, err := crypto.ToECDSA(math.PaddedBigBytes(key, 32))
+	if err != nil {
+		return nil, err
+	}
+	return NewKeySigner(pk), nil
+}
+
+// hardenedKeyStart is the first hardened index (the ' in a path)
+const hardenedKeyStart = 0x80000000
+
+var errInvalidChild = errors.New("invalid child key, use the next index")
+
+// masterKey derives the BIP-32 master key and chain code of a seed
+func masterKey(seed []byte) (*big.Int, []byte, error) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	key := new(big.Int).SetBytes(sum[:32])
+	if key.Sign() == 0 || key.Cmp(crypto.S256().Params().N) >= 0 {
+		return nil, nil, errors.New("invalid master key")
+	}
+	return key, sum[32:], nil
+}
+
+// childKey derives the private child key at index; indexes from hardenedKeyStart are hardened
+func childKey(parent *big.Int, chainCode []byte, index uint32) (*big.Int, []byte, error) {
+	var data []byte
+	if index >= hardenedKeyStart {
+		data = append([]byte{0}, math.PaddedBigBytes(parent, 32)...)
+	} else {
+		pk, err := crypto.ToECDSA(math.PaddedBigBytes(parent, 32))
+		if err != nil {
+			return nil, nil, err
+		}
+		data = crypto.CompressPubkey(&pk.PublicKey)
+	}
+	data = binary.BigEndian.AppendUint32(data, index)
+
+	mac := hmac.New(sha512.New, chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	n := crypto.S256().Params().N
+	tweak := new(big.Int).SetBytes(sum[:32])
+	if tweak.Cmp(n) >= 0 {
+		return nil, nil, errInvalidChild
+	}
+	child := tweak.Add(tweak, parent)
+	child.Mod(child, n)
+	if child.Sign() == 0 {
+		return nil, nil, errInvalidChild
+	}
+	return child, sum[32:], nil
+}
@@ -0,0 +1,45 @@
+package signer
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestFromMnemonic(t *testing.T) {
+	cases := []struct {
+		mnemonic string
+		path     string
+		want     string
+	}{
+		{"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", DefaultPath,
+			"0x9858EfFD232B4033E47d90003D41EC34EcaEda94"},
+		{"test test test test test test test test test test test junk", DefaultPath,
+			"0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"},
+		{"test test test test test test test test test test test junk", "m/44'/60'/0'/0/1",
+			"0x70997970C51812dc3A010C7d01b50e0d17dc79C8"},
+	}
+	for _, tc := range cases {
+		s, err := FromMnemonic(tc.mnemonic, "", tc.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Address() != common.HexToAddress(tc.want) {
+			t.Errorf("%s at %s = %s, want %s", tc.mnemonic, tc.path, s.Address().Hex(), tc.want)
+		}
+	}
+
+	// A passphrase selects another wallet
+	s, _ := FromMnemonic(cases[0].mnemonic, "secret", DefaultPath)
+	if s == nil || s.Address() == common.HexToAddress(cases[0].want) {
+		t.Error("expected the passphrase to change the derived account")
+	}
+
+	// A typo breaks the checksum instead of silently deriving another wallet
+	if _, err := FromMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon", "", DefaultPath); err == nil {
+		t.Error("expected a checksum error")
+	}
+	if _, err := FromMnemonic(cases[0].mnemonic, "", "m/44'/60'/x"); err == nil {
+		t.Error("expected an invalid path error")
+	}
+}
@@ -0,0 +1,184 @@
+package signer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// RemoteSigner delegates signing to a service holding the key, such as web3signer,
+// over the Ethereum JSON-RPC signing methods (eth_signTypedData, eth_signTransaction).
+// clef serves a different API (account_*) and is not supported. The key never leaves
+// the service; every signature is checked to recover to the account before use.
+type RemoteSigner struct {
+	URL    string
+	Client *http.Client
+
+	address common.Address
+	id      atomic.Int64
+}
+
+// NewRemoteSigner connects to a signing service. With a zero address it signs as
+// the first account the service lists (eth_accounts).
+func NewRemoteSigner(ctx context.Context, url string, address common.Address) (*RemoteSigner, error) {
+	s := &RemoteSigner{
+		URL:     url,
+		Client:  &http.Client{Timeout: 10 * time.Second},
+		address: address,
+	}
+	if address != (common.Address{}) {
+		return s, nil
+	}
+
+	var accounts []common.Address
+	if err := s.call(ctx, &accounts, "eth_accounts"); err != nil {
+		return nil, err
+	}
+	if len(accounts) == 0 {
+		return nil, fmt.Errorf("signer %s has no accounts", url)
+	}
+	s.address = accounts[0]
+	return s, nil
+}
+
+func (s *RemoteSigner) Address() common.Address {
+	return s.address
+}
+
+func (s *RemoteSigner) SignTypedData(ctx context.Context, typedData apitypes.TypedData) ([]byte, error) {
+	var sig hexutil.Bytes
+	if err := s.call(ctx, &sig, "eth_signTypedData", s.address, typedData); err != nil {
+		return nil, err
+	}
+	signer, err := Recover(typedData, sig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature from %s: %w", s.URL, err)
+	}
+	if signer != s.address {
+		return nil, fmt.Errorf("signature from %s recovers to %s, not %s", s.URL, signer.Hex(), s.address.Hex())
+	}
+	if sig[64] < 27 {
+		sig[64] += 27
+	}
+	return sig, nil
+}
+
+// txArgs is the transaction object of eth_signTransaction
+type txArgs struct {
+	From                 common.Address  `json:"from"`
+	To                   *common.Address `json:"to,omitempty"`
+	Gas                  hexutil.Uint64  `json:"gas"`
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas"`
+	Value                *hexutil.Big    `json:"value"`
+	Nonce                hexutil.Uint64  `json:"nonce"`
+	Data                 hexutil.Bytes   `json:"data"`
+	ChainID              *hexutil.Big    `json:"chainId"`
+}
+
+func (s *RemoteSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	if tx.Type() != types.DynamicFeeTxType {
+		return nil, fmt.Errorf("remote signing of type %d transactions is not supported", tx.Type())
+	}
+	args := txArgs{
+		From:                 s.address,
+		To:                   tx.To(),
+		Gas:                  hexutil.Uint64(tx.Gas()),
+		MaxFeePerGas:         (*hexutil.Big)(tx.GasFeeCap()),
+		MaxPriorityFeePerGas: (*hexutil.Big)(tx.GasTipCap()),
+		Value:                (*hexutil.Big)(tx.Value()),
+		Nonce:                hexutil.Uint64(tx.Nonce()),
+		Data:                 tx.Data(),
+		ChainID:              (*hexutil.Big)(chainID),
+	}
+	var raw hexutil.Bytes
+	if err := s.call(ctx, &raw, "eth_signTransaction", args); err != nil {
+		return nil, err
+	}
+
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(raw); err != nil {
+		return nil, fmt.Errorf("invalid transaction from %s: %w", s.URL, err)
+	}
+	// The service must have signed exactly the transaction asked for
+	txSigner := types.LatestSignerForChainID(chainID)
+	if txSigner.Hash(signed) != txSigner.Hash(tx) {
+		return nil, fmt.Errorf("signer %s returned a different transaction", s.URL)
+	}
+	if from, err := types.Sender(txSigner, signed); err != nil || from != s.address {
+		return nil, fmt.Errorf("transaction from %s is not signed by %s", s.URL, s.address.Hex())
+	}
+	return signed, nil
+}
+
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      int64         `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *RPCError       `json:"error"`
+}
+
+// RPCError is an error returned by the signing service, e.g. a refusal
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("signer error %d: %s", e.Code, e.Message)
+}
+
+// call runs a JSON-RPC method and decodes its result into out
+func (s *RemoteSigner) call(ctx context.Context, out interface{}, method string, params ...interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	body, err := json.Marshal(rpcRequest{JSONRPC: "2.0", ID: s.id.Add(1), Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: signer returned HTTP %d", method, resp.StatusCode)
+	}
+
+	var res rpcResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return fmt.Errorf("%s: decoding response: %w", method, err)
+	}
+	if res.Error != nil {
+		return fmt.Errorf("%s: %w", method, res.Error)
+	}
+	if len(res.Result) == 0 || string(res.Result) == "null" {
+		return fmt.Errorf("%s: %w", method, errEmptyResult)
+	}
+	return json.Unmarshal(res.Result, out)
+}
+
+var errEmptyResult = errors.New("empty result")
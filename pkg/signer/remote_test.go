@@ -0,0 +1,129 @@
+package signer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// newSignerStub serves the JSON-RPC signing methods with key, the way web3signer does
+func newSignerStub(t *testing.T, key *KeySigner) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     int64             `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("bad request: %v", err)
+			return
+		}
+
+		var result interface{}
+		var rpcErr *RPCError
+		switch req.Method {
+		case "eth_accounts":
+			result = []common.Address{key.Address()}
+		case "eth_signTypedData":
+			var td apitypes.TypedData
+			json.Unmarshal(req.Params[1], &td)
+			sig, err := key.SignTypedData(r.Context(), td)
+			if err != nil {
+				rpcErr = &RPCError{Code: -32000, Message: err.Error()}
+				break
+			}
+			sig[64] -= 27 // Some services answer with V as 0/1
+			result = hexutil.Bytes(sig)
+		case "eth_signTransaction":
+			var args txArgs
+			json.Unmarshal(req.Params[0], &args)
+			tx, err := key.SignTx(r.Context(), types.NewTx(&types.DynamicFeeTx{
+				ChainID: args.ChainID.ToInt(), Nonce: uint64(args.Nonce), GasTipCap: args.MaxPriorityFeePerGas.ToInt(),
+				GasFeeCap: args.MaxFeePerGas.ToInt(), Gas: uint64(args.Gas), To: args.To, Value: args.Value.ToInt(), Data: args.Data,
+			}), args.ChainID.ToInt())
+			if err != nil {
+				rpcErr = &RPCError{Code: -32000, Message: err.Error()}
+				break
+			}
+			raw, _ := tx.MarshalBinary()
+			result = hexutil.Bytes(raw)
+		default:
+			rpcErr = &RPCError{Code: -32601, Message: "method not found"}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result, "error": rpcErr})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRemoteSigner(t *testing.T) {
+	ctx := context.Background()
+	key, _ := FromHex(testPrivateKey)
+	srv := newSignerStub(t, key)
+
+	// The account is discovered from the service
+	s, err := NewRemoteSigner(ctx, srv.URL, common.Address{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Address() != testAddress {
+		t.Fatalf("address = %s, want %s", s.Address().Hex(), testAddress.Hex())
+	}
+
+	// Same signature as signing locally, V normalized to 27/28
+	sig, err := s.SignTypedData(ctx, testTypedData())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := key.SignTypedData(ctx, testTypedData())
+	if hexutil.Encode(sig) != hexutil.Encode(want) {
+		t.Errorf("signature = %x, want %x", sig, want)
+	}
+
+	tx, err := s.SignTx(ctx, testTx(), big.NewInt(137))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Hash() == testTx().Hash() || tx.Nonce() != 3 {
+		t.Errorf("expected a signed copy of the transaction, got %+v", tx)
+	}
+}
+
+func TestRemoteSignerRejectsForeignSignatures(t *testing.T) {
+	ctx := context.Background()
+	other, _ := crypto.GenerateKey()
+	srv := newSignerStub(t, NewKeySigner(other))
+
+	// The service signs with another key than the configured account
+	s, err := NewRemoteSigner(ctx, srv.URL, testAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SignTypedData(ctx, testTypedData()); err == nil {
+		t.Error("expected a signature by another account to be rejected")
+	}
+	if _, err := s.SignTx(ctx, testTx(), big.NewInt(137)); err == nil {
+		t.Error("expected a transaction signed by another account to be rejected")
+	}
+
+	// Refusals come back as RPC errors
+	legacy := types.NewTx(&types.LegacyTx{Nonce: 1, Gas: 21000, GasPrice: big.NewInt(1)})
+	if _, err := s.SignTx(ctx, legacy, big.NewInt(137)); err == nil {
+		t.Error("expected legacy transactions to be refused")
+	}
+	var rpcErr *RPCError
+	if err := s.call(ctx, nil, "eth_sign"); !errors.As(err, &rpcErr) || rpcErr.Code != -32601 {
+		t.Errorf("expected a method not found RPCError, got %v", err)
+	}
+}
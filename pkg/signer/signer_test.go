@@ -0,0 +1,73 @@
+package signer
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// Well-known development key (anvil/hardhat account 0)
+const testPrivateKey = "0xac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+
+var testAddress = common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266")
+
+// testTypedData is a minimal EIP-712 message
+func testTypedData() apitypes.TypedData {
+	return apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {{Name: "name", Type: "string"}, {Name: "chainId", Type: "uint256"}},
+			"Ping":         {{Name: "nonce", Type: "uint256"}, {Name: "note", Type: "string"}},
+		},
+		PrimaryType: "Ping",
+		Domain:      apitypes.TypedDataDomain{Name: "Test", ChainId: (*math.HexOrDecimal256)(big.NewInt(137))},
+		Message:     apitypes.TypedDataMessage{"nonce": "7", "note": "hello"},
+	}
+}
+
+// testTx is an unsigned EIP-1559 transaction
+func testTx() *types.Transaction {
+	to := common.HexToAddress("0x4D97DCd97eC945f40cF65F87097ACe5EA0476045")
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID: big.NewInt(137), Nonce: 3, GasTipCap: big.NewInt(30e9), GasFeeCap: big.NewInt(90e9),
+		Gas: 150_000, To: &to, Value: big.NewInt(0), Data: []byte{0xde, 0xad},
+	})
+}
+
+func TestKeySigner(t *testing.T) {
+	ctx := context.Background()
+	s, err := FromHex(testPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Address() != testAddress {
+		t.Errorf("address = %s, want %s", s.Address().Hex(), testAddress.Hex())
+	}
+
+	sig, err := s.SignTypedData(ctx, testTypedData())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig[64] != 27 && sig[64] != 28 {
+		t.Errorf("expected V of 27 or 28, got %d", sig[64])
+	}
+	if signer, err := Recover(testTypedData(), sig); err != nil || signer != testAddress {
+		t.Errorf("signature recovers to %s (err %v)", signer.Hex(), err)
+	}
+
+	tx, err := s.SignTx(ctx, testTx(), big.NewInt(137))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from, err := types.Sender(types.LatestSignerForChainID(big.NewInt(137)), tx); err != nil || from != testAddress {
+		t.Errorf("transaction signed by %s (err %v)", from.Hex(), err)
+	}
+
+	if _, err := FromHex("0x1234"); err == nil {
+		t.Error("expected an invalid key error")
+	}
+}
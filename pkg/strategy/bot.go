@@ -1,20 +1,26 @@
 package strategy
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"math"
 	"time"
 
 	"poly/pkg/config"
 	"poly/pkg/exchange"
 	"poly/pkg/market"
+	"poly/pkg/portfolio"
 )
 
 // State represents the bot's current state in the cycle
 type State int
 
 const (
-	StateWatching State = iota
+	StateWatching    State = iota
+	StateLeg1Pending       // Leg 1 order placed, waiting for the fill
 	StateLeg1Bought
+	StateLeg2Pending // Hedge order placed, waiting for the fill
 	StateDone
 )
 
@@ -22,6 +28,7 @@ type Bot struct {
 	cfg      *config.Config
 	exchange exchange.Exchange
 	state    State
+	marketID string // cfg.MarketID, or the market of the current round
 
 	// Market Data
 	bufferUp   *market.PriceBuffer
@@ -29,18 +36,63 @@ type Bot struct {
 
 	// Cycle State
 	leg1Side       exchange.Side
-	leg1EntryPrice float64
+	leg1EntryPrice exchange.Amount // Per-share fill price
+	leg1Cost       exchange.Amount // Total USDC spent on leg 1
+	leg1Shares     float64         // Shares actually filled on leg 1
+	hedgedShares   float64         // Shares of leg 1 already hedged
+	leg2Cost       exchange.Amount // Total USDC spent on the hedge so far
 	roundStartTime time.Time
+
+	// Shares held across cycles and markets, fed by the leg fills
+	portfolio *portfolio.Portfolio
+	merger    Merger   // Turns hedged pairs back into USDC, nil to hold them until resolution
+	redeemer  Redeemer // Cashes in the shares of resolved markets, nil to leave them
+	cycle     *Cycle   // Record of the current cycle, nil until leg 1 fills
+	cycles    []*Cycle
+
+	settlements []*pendingTx // Merges and redemptions sent, booked once mined
+
+	unbooked   []*exchange.Order // Filled leg orders whose trades are not all booked yet
+	rolledBack map[string]bool   // Failed trades taken back, by trade and order id
+
+	lastRedeemCheck time.Time
+
+	// Recurring markets, nil when trading the single cfg.MarketID
+	rounds        *RoundScheduler
+	round         *Round
+	roundFailures int       // Failed lookups in a row
+	roundRetryAt  time.Time // No lookup before then after a failure
+
+	// Order awaiting confirmation
+	pendingOrder   *exchange.Order
+	pendingSince   time.Time
+	pendingUnknown bool // The placement failed ambiguously: the order may not exist
+	ordersPlaced   int  // Numbers the client ids of leg orders
+
+	// Push sources used by Run, nil when polling
+	tickerSource exchange.TickerSource
+	eventSource  exchange.UserEventSource
+	errs         chan error
+	halted       error // Set by an error that makes trading impossible
 }
 
 func NewBot(cfg *config.Config, exc exchange.Exchange) *Bot {
+	// Known token ids spare the exchange a market lookup
+	if reg, ok := exc.(marketRegistry); ok && cfg.TokenIDUp != "" && cfg.TokenIDDown != "" {
+		reg.RegisterMarket(exchange.Market{ID: cfg.MarketID, TokenUp: cfg.TokenIDUp, TokenDown: cfg.TokenIDDown})
+	}
+
 	return &Bot{
 		cfg:            cfg,
 		exchange:       exc,
 		state:          StateWatching,
+		marketID:       cfg.MarketID,
 		bufferUp:       market.NewPriceBuffer(5 * time.Second), // Keep 5s history
 		bufferDown:     market.NewPriceBuffer(5 * time.Second),
 		roundStartTime: exc.CurrentTime(), // Assume round starts when bot starts for simplicity, or fetch from API
+		portfolio:      portfolio.New(),
+		rolledBack:     make(map[string]bool),
+		errs:           make(chan error, 16),
 	}
 }
 
@@ -50,19 +102,61 @@ func (b *Bot) ResetCycle() {
 	b.state = StateWatching
 	b.leg1Side = ""
 	b.leg1EntryPrice = 0
+	b.leg1Cost = 0
+	b.leg1Shares = 0
+	b.hedgedShares = 0
+	b.leg2Cost = 0
+	b.pendingOrder = nil
+	b.pendingUnknown = false
+	b.cycle = nil
 	b.roundStartTime = b.exchange.CurrentTime()
 	// Clear buffers? No, keep them for continuity or clear if different market
 }
 
+// resetBuffers drops the price history, e.g. when switching markets
+func (b *Bot) resetBuffers() {
+	b.bufferUp = market.NewPriceBuffer(5 * time.Second)
+	b.bufferDown = market.NewPriceBuffer(5 * time.Second)
+}
+
 // RunTick executes one tick of logic
-func (b *Bot) RunTick() {
+func (b *Bot) RunTick(ctx context.Context) {
+	if b.halted != nil {
+		return
+	}
 	now := b.exchange.CurrentTime()
-	ticker, err := b.exchange.GetTicker(b.cfg.MarketID)
+	b.updateRound(ctx, now)
+	b.bookTrades(ctx)
+	b.reconcileSettlements(ctx)
+	b.redeemResolved(ctx, now)
+
+	callCtx, cancel := b.withTimeout(ctx, b.cfg.RequestTimeout)
+	ticker, err := b.exchange.GetTicker(callCtx, b.marketID)
+	cancel()
 	if err != nil {
-		log.Printf("Error fetching ticker: %v", err)
+		b.handleError(fmt.Errorf("fetching ticker: %w", err))
+		return
+	}
+	b.HandleTicker(ctx, ticker)
+}
+
+// HandleTicker runs the strategy on a ticker, either polled or pushed by a stream
+func (b *Bot) HandleTicker(ctx context.Context, ticker *exchange.Ticker) {
+	if b.halted != nil {
 		return
 	}
 
+	// Time the dump detection by when the prices were seen, not when they were processed
+	now := ticker.Timestamp
+	if now.IsZero() {
+		now = b.exchange.CurrentTime()
+	}
+
+	b.updateRound(ctx, now)
+	if b.marketID != "" && ticker.MarketID != b.marketID {
+		return // A stream may carry other markets
+	}
+
 	// Update Buffers
 	b.bufferUp.Add(ticker.PriceUp, now)
 	b.bufferDown.Add(ticker.PriceDown, now)
@@ -70,15 +164,17 @@ func (b *Bot) RunTick() {
 	// Logic Switch
 	switch b.state {
 	case StateWatching:
-		b.checkLeg1(ticker, now)
+		b.checkLeg1(ctx, ticker, now)
+	case StateLeg1Pending, StateLeg2Pending:
+		b.pollPendingOrder(ctx)
 	case StateLeg1Bought:
-		b.checkLeg2(ticker)
+		b.checkLeg2(ctx, ticker, now)
 	case StateDone:
 		// Wait for next round (handled externally or by checking round ID change)
 	}
 }
 
-func (b *Bot) checkLeg1(ticker *exchange.Ticker, now time.Time) {
+func (b *Bot) checkLeg1(ctx context.Context, ticker *exchange.Ticker, now time.Time) {
 	// Check window
 	elapsed := now.Sub(b.roundStartTime)
 	if elapsed > b.cfg.WindowMin {
@@ -96,7 +192,7 @@ func (b *Bot) checkLeg1(ticker *exchange.Ticker, now time.Time) {
 		drop := (priceUp3sAgo - ticker.PriceUp) / priceUp3sAgo
 		if drop >= b.cfg.MovePct {
 			log.Printf("DETECTED DUMP on UP! Drop: %.2f%% (%.3f -> %.3f)", drop*100, priceUp3sAgo, ticker.PriceUp)
-			b.executeLeg1(exchange.SideUp, ticker.PriceUp)
+			b.executeLeg1(ctx, exchange.SideUp, ticker.PriceUp, now)
 			return
 		}
 	}
@@ -107,63 +203,148 @@ func (b *Bot) checkLeg1(ticker *exchange.Ticker, now time.Time) {
 		drop := (priceDown3sAgo - ticker.PriceDown) / priceDown3sAgo
 		if drop >= b.cfg.MovePct {
 			log.Printf("DETECTED DUMP on DOWN! Drop: %.2f%% (%.3f -> %.3f)", drop*100, priceDown3sAgo, ticker.PriceDown)
-			b.executeLeg1(exchange.SideDown, ticker.PriceDown)
+			b.executeLeg1(ctx, exchange.SideDown, ticker.PriceDown, now)
 			return
 		}
 	}
 }
 
-func (b *Bot) executeLeg1(side exchange.Side, price float64) {
+func (b *Bot) executeLeg1(ctx context.Context, side exchange.Side, price float64, now time.Time) {
 	log.Printf(">>> EXECUTING LEG 1: Buy %s @ %.3f", side, price)
 
-	order, err := b.exchange.PlaceOrder(b.cfg.MarketID, side, b.cfg.Shares, price)
+	if err := b.checkFunds(ctx, price); err != nil {
+		b.legFailed(fmt.Errorf("refusing leg 1: %w", err))
+		return
+	}
+
+	opts := b.orderOptions(b.cfg.Leg1OrderType, 1, now)
+	callCtx, cancel := b.withTimeout(ctx, b.cfg.OrderTimeout)
+	order, err := b.exchange.PlaceOrder(callCtx, b.marketID, side, exchange.DirectionBuy, b.cfg.Shares, price, opts)
+	cancel()
 	if err != nil {
-		log.Printf("Failed to place Leg 1 order: %v", err)
+		placed := &exchange.Order{MarketID: b.marketID, Side: side, Direction: exchange.DirectionBuy, Price: price, Size: b.cfg.Shares}
+		if b.trackUnknownOrder(ctx, fmt.Errorf("placing leg 1 order: %w", err), placed, StateLeg1Pending) {
+			b.leg1Side = side
+			return
+		}
+		b.legFailed(fmt.Errorf("placing leg 1 order: %w", err))
 		return
 	}
 
 	b.leg1Side = side
-	b.leg1EntryPrice = order.Price // Use actual fill price
-	b.state = StateLeg1Bought
-	log.Printf("Leg 1 Filled. Waiting for Hedge (Target Sum <= %.2f)...", b.cfg.SumTarget)
+	b.trackOrder(ctx, order, StateLeg1Pending)
 }
 
-func (b *Bot) checkLeg2(ticker *exchange.Ticker) {
-	var oppositePrice float64
-	var oppositeSide exchange.Side
+// checkFunds makes sure the funder can pay for leg 1 and for hedging it. The hedge is
+// only bought while the pair costs at most SumTarget, so Shares * SumTarget covers both.
+func (b *Bot) checkFunds(ctx context.Context, price float64) error {
+	perShare := math.Max(b.cfg.SumTarget, price) * (1 + b.cfg.FeeRate)
+	need := exchange.AmountFromFloat(b.cfg.Shares).Mul(exchange.AmountFromFloat(perShare), exchange.RoundUp)
 
+	callCtx, cancel := b.withTimeout(ctx, b.cfg.RequestTimeout)
+	defer cancel()
+	bal, err := b.exchange.GetCollateralBalance(callCtx, b.marketID)
+	if err != nil {
+		return fmt.Errorf("checking balance: %w", err)
+	}
+	if avail := bal.Available(); avail < need {
+		return fmt.Errorf("%w: leg 1 and its hedge need %s USDC, %s available (balance %s, allowance %s)",
+			exchange.ErrInsufficientBalance, need, avail, bal.Balance, bal.Allowance)
+	}
+	return nil
+}
+
+// onLeg1Filled records the confirmed leg 1 fill and starts waiting for the hedge
+func (b *Bot) onLeg1Filled(ctx context.Context, order *exchange.Order) {
+	b.openCycle(b.exchange.CurrentTime())
+	b.leg1EntryPrice = exchange.AmountFromFloat(order.FillPrice()) // Use actual fill price
+	b.leg1Cost = order.Cost()
+	b.leg1Shares = filledShares(order)
+	b.state = StateLeg1Bought
+	log.Printf("Leg 1 Filled: %.2f shares @ %s. Waiting for Hedge (Target Sum <= %.2f)...",
+		b.leg1Shares, b.leg1EntryPrice, b.cfg.SumTarget)
+	b.recordFill(ctx, order)
+}
+
+func (b *Bot) checkLeg2(ctx context.Context, ticker *exchange.Ticker, now time.Time) {
+	oppositeSide := exchange.SideUp
 	if b.leg1Side == exchange.SideUp {
-		oppositePrice = ticker.PriceDown
 		oppositeSide = exchange.SideDown
-	} else {
-		oppositePrice = ticker.PriceUp
-		oppositeSide = exchange.SideUp
 	}
+	oppositePrice := ticker.Price(oppositeSide)
 
-	currentSum := b.leg1EntryPrice + oppositePrice
+	// Exact fixed-point sum, so the boundary case does not depend on float rounding
+	currentSum := b.leg1EntryPrice.Add(oppositePrice)
 
 	// Strategy: leg1EntryPrice + oppositeAsk <= sumTarget
-	if currentSum <= b.cfg.SumTarget {
-		log.Printf("HEDGE CONDITION MET! Sum: %.3f (Entry: %.3f + Opp: %.3f) <= Target: %.3f",
+	if currentSum <= exchange.AmountFromFloat(b.cfg.SumTarget) {
+		log.Printf("HEDGE CONDITION MET! Sum: %s (Entry: %s + Opp: %s) <= Target: %.3f",
 			currentSum, b.leg1EntryPrice, oppositePrice, b.cfg.SumTarget)
 
-		b.executeLeg2(oppositeSide, oppositePrice)
+		b.executeLeg2(ctx, oppositeSide, oppositePrice, now)
 	}
 }
 
-func (b *Bot) executeLeg2(side exchange.Side, price float64) {
-	log.Printf(">>> EXECUTING LEG 2 (HEDGE): Buy %s @ %.3f", side, price)
+func (b *Bot) executeLeg2(ctx context.Context, side exchange.Side, price exchange.Amount, now time.Time) {
+	size := b.leg1Shares - b.hedgedShares
 
-	order, err := b.exchange.PlaceOrder(b.cfg.MarketID, side, b.cfg.Shares, price)
+	// A remainder left by a partial fill may be too small to place: hedge the
+	// minimum instead, the few extra shares still pay out if their side wins
+	callCtx, cancel := b.withTimeout(ctx, b.cfg.RequestTimeout)
+	info, err := b.exchange.GetMarketInfo(callCtx, b.marketID)
+	cancel()
 	if err != nil {
-		log.Printf("Failed to place Leg 2 order: %v", err)
+		b.legFailed(fmt.Errorf("looking up market %s for the hedge: %w", b.marketID, err))
 		return
 	}
+	if minSize := info.MinOrderSize.Float64(); size < minSize {
+		log.Printf("Hedge remainder %.2f below the market minimum %s, rounding up", size, info.MinOrderSize)
+		size = minSize
+	}
+	log.Printf(">>> EXECUTING LEG 2 (HEDGE): Buy %.2f %s @ %s", size, side, price)
 
-	totalCost := b.leg1EntryPrice + order.Price
-	profit := 1.0 - totalCost // Since we hold 1 share of YES and 1 share of NO, payout is $1.0
-	roi := (profit / totalCost) * 100
+	opts := b.orderOptions(b.cfg.Leg2OrderType, 2, now)
+	callCtx, cancel = b.withTimeout(ctx, b.cfg.OrderTimeout)
+	order, err := b.exchange.PlaceOrder(callCtx, b.marketID, side, exchange.DirectionBuy, size, price.Float64(), opts)
+	cancel()
+	if err != nil {
+		placed := &exchange.Order{MarketID: b.marketID, Side: side, Direction: exchange.DirectionBuy, Price: price.Float64(), Size: size}
+		if !b.trackUnknownOrder(ctx, fmt.Errorf("placing leg 2 order: %w", err), placed, StateLeg2Pending) {
+			b.legFailed(fmt.Errorf("placing leg 2 order: %w", err))
+		}
+		return
+	}
+
+	b.trackOrder(ctx, order, StateLeg2Pending)
+}
+
+// onLeg2Filled accounts for a (possibly partial) hedge fill
+func (b *Bot) onLeg2Filled(ctx context.Context, order *exchange.Order) {
+	b.hedgedShares += filledShares(order)
+	b.leg2Cost = b.leg2Cost.Add(order.Cost())
+	b.recordFill(ctx, order)
 
-	log.Printf("CYCLE COMPLETE. Total Cost: %.3f, Profit per share: %.3f, ROI: %.2f%%", totalCost, profit, roi)
+	if b.hedgedShares < b.leg1Shares {
+		// Hedge the remainder on a later tick
+		log.Printf("Leg 2 partially filled: %.2f / %.2f shares hedged", b.hedgedShares, b.leg1Shares)
+		b.state = StateLeg1Bought
+		return
+	}
+
+	totalCost := b.leg1Cost.Add(b.leg2Cost)
+	payout := exchange.AmountFromFloat(b.leg1Shares) // Each YES+NO pair pays out $1.0
+	profit := payout.Sub(totalCost)
+	// Rolled back fills may leave nothing to divide by
+	var roi float64
+	if totalCost > 0 {
+		roi = profit.Float64() / totalCost.Float64() * 100
+	}
+	var costPerShare exchange.Amount
+	if payout > 0 {
+		costPerShare = totalCost.Quo(payout, exchange.RoundHalfUp)
+	}
+	log.Printf("CYCLE COMPLETE. Total Cost: %s (%s per share), Profit: %s, ROI: %.2f%%", totalCost, costPerShare, profit, roi)
 	b.state = StateDone
+
+	b.mergeHedged(ctx)
 }
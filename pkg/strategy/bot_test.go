@@ -1,6 +1,9 @@
 package strategy
 
 import (
+	"context"
+	"errors"
+	"slices"
 	"testing"
 	"time"
 
@@ -9,6 +12,7 @@ import (
 )
 
 func TestBotLogic(t *testing.T) {
+	ctx := context.Background()
 	cfg := config.DefaultConfig()
 	cfg.MovePct = 0.10   // 10% drop trigger
 	cfg.SumTarget = 0.96 // Slightly higher to avoid float precision issues at boundary
@@ -18,16 +22,16 @@ func TestBotLogic(t *testing.T) {
 
 	// Initial State
 	mockExc.SetPrice(0.50, 0.50)
-	bot.RunTick() // Fill buffer
+	bot.RunTick(ctx) // Fill buffer
 
 	// Advance 3 seconds
 	mockExc.AdvanceTime(3 * time.Second)
-	bot.RunTick()
+	bot.RunTick(ctx)
 
 	// Trigger Dump on UP: 0.50 -> 0.40 (20% drop > 10%)
 	mockExc.AdvanceTime(1 * time.Second)
 	mockExc.SetPrice(0.40, 0.55)
-	bot.RunTick()
+	bot.RunTick(ctx)
 
 	if bot.state != StateLeg1Bought {
 		t.Errorf("Expected state Leg1Bought, got %v", bot.state)
@@ -44,7 +48,438 @@ func TestBotLogic(t *testing.T) {
 	// switch b.state { case StateWatching: checkLeg1 ... if executed -> b.state = Leg1Bought }
 	// It breaks after checkLeg1. So need another tick to check Leg2.
 
-	bot.RunTick()
+	bot.RunTick(ctx)
+
+	if bot.state != StateDone {
+		t.Errorf("Expected state Done, got %v", bot.state)
+	}
+
+	// Both legs are booked; the hedged pair pays 20 at resolution for 19
+	p := bot.Portfolio()
+	up, down := p.Position(cfg.MarketID, exchange.SideUp), p.Position(cfg.MarketID, exchange.SideDown)
+	if up.Shares != exchange.AmountFromFloat(20) || down.Shares != exchange.AmountFromFloat(20) {
+		t.Errorf("Expected 20 shares of each side, got %s UP and %s DOWN", up.Shares, down.Shares)
+	}
+	if cost := up.Cost.Add(down.Cost); cost != exchange.AmountFromFloat(19) {
+		t.Errorf("Expected a cost basis of 19, got %s", cost)
+	}
+}
+
+// restingExchange bids below the ask so leg orders rest on the book
+type restingExchange struct {
+	*exchange.MockExchange
+}
+
+func (r *restingExchange) PlaceOrder(ctx context.Context, marketID string, side exchange.Side, dir exchange.Direction, size float64, price float64, opts exchange.OrderOptions) (*exchange.Order, error) {
+	return r.MockExchange.PlaceOrder(ctx, marketID, side, dir, size, price-0.05, opts)
+}
+
+func TestBotWaitsForConfirmedFill(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.DefaultConfig()
+	cfg.MovePct = 0.10
+	cfg.FillTimeout = 5 * time.Second
+	cfg.Leg1OrderType = "GTC"
+
+	mockExc := &restingExchange{exchange.NewMockExchange()}
+	bot := NewBot(cfg, mockExc)
+
+	mockExc.SetPrice(0.50, 0.50)
+	bot.RunTick(ctx)
+	mockExc.AdvanceTime(3 * time.Second)
+	bot.RunTick(ctx)
+
+	// Dump on UP, but the order rests at 0.35
+	mockExc.AdvanceTime(1 * time.Second)
+	mockExc.SetPrice(0.40, 0.55)
+	bot.RunTick(ctx)
+	if bot.state != StateLeg1Pending {
+		t.Fatalf("Expected state Leg1Pending, got %v", bot.state)
+	}
+
+	// Nothing fills before the timeout: the order is pulled
+	mockExc.AdvanceTime(6 * time.Second)
+	bot.RunTick(ctx)
+	if bot.state != StateWatching {
+		t.Fatalf("Expected state Watching after timeout, got %v", bot.state)
+	}
+	if open, _ := mockExc.OpenOrders(ctx, cfg.MarketID); len(open) != 0 {
+		t.Errorf("Expected stale order to be cancelled, %d still open", len(open))
+	}
+
+	// Next dump: the resting order fills when the ask drops through it
+	mockExc.AdvanceTime(10 * time.Second)
+	bot.ResetCycle()
+	mockExc.SetPrice(0.50, 0.50)
+	bot.RunTick(ctx)
+	mockExc.AdvanceTime(3 * time.Second)
+	bot.RunTick(ctx)
+	mockExc.AdvanceTime(1 * time.Second)
+	mockExc.SetPrice(0.40, 0.70)
+	bot.RunTick(ctx)
+
+	mockExc.AdvanceTime(1 * time.Second)
+	mockExc.SetPrice(0.34, 0.70)
+	bot.RunTick(ctx)
+	if bot.state != StateLeg1Bought {
+		t.Fatalf("Expected state Leg1Bought, got %v", bot.state)
+	}
+	if bot.leg1EntryPrice != exchange.AmountFromFloat(0.34) {
+		t.Errorf("Expected entry at the fill price 0.34, got %s", bot.leg1EntryPrice)
+	}
+}
+
+func TestBotFAKEntryTakesAvailableDepth(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.DefaultConfig()
+	cfg.MovePct = 0.10
+	cfg.SumTarget = 0.90 // No immediate hedge
+	cfg.Leg1OrderType = "FAK"
+
+	mockExc := exchange.NewMockExchange()
+	mockExc.Depth = 12
+	bot := NewBot(cfg, mockExc)
+
+	mockExc.SetPrice(0.50, 0.50)
+	bot.RunTick(ctx)
+	mockExc.AdvanceTime(3 * time.Second)
+	bot.RunTick(ctx)
+
+	mockExc.AdvanceTime(1 * time.Second)
+	mockExc.SetPrice(0.40, 0.55)
+	bot.RunTick(ctx)
+
+	// Only 12 of the 20 shares were offered; the rest was killed
+	if bot.state != StateLeg1Bought {
+		t.Fatalf("Expected state Leg1Bought, got %v", bot.state)
+	}
+	if bot.leg1Shares != 12 {
+		t.Errorf("Expected 12 shares filled, got %v", bot.leg1Shares)
+	}
+}
+
+func TestBotAdvancesOnUserEvents(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.DefaultConfig()
+	cfg.MovePct = 0.10
+	cfg.SumTarget = 0.90 // No immediate hedge
+	cfg.Leg1OrderType = "GTC"
+
+	mockExc := &restingExchange{exchange.NewMockExchange()}
+	events := mockExc.UserEvents()
+	bot := NewBot(cfg, mockExc)
+	drain := func() {
+		for {
+			select {
+			case ev := <-events:
+				bot.HandleUserEvent(ctx, ev)
+			default:
+				return
+			}
+		}
+	}
+
+	mockExc.SetPrice(0.50, 0.50)
+	bot.RunTick(ctx)
+	mockExc.AdvanceTime(3 * time.Second)
+	bot.RunTick(ctx)
+	mockExc.AdvanceTime(1 * time.Second)
+	mockExc.SetPrice(0.40, 0.55)
+	bot.RunTick(ctx)
+	drain()
+	if bot.state != StateLeg1Pending {
+		t.Fatalf("Expected state Leg1Pending, got %v", bot.state)
+	}
+
+	// The fill is pushed: no tick is needed to pick it up
+	mockExc.SetPrice(0.34, 0.55)
+	drain()
+	if bot.state != StateLeg1Bought {
+		t.Fatalf("Expected state Leg1Bought after the fill event, got %v", bot.state)
+	}
+	if bot.leg1EntryPrice != exchange.AmountFromFloat(0.34) {
+		t.Errorf("Expected entry at the fill price 0.34, got %s", bot.leg1EntryPrice)
+	}
+}
+
+func TestBotRefusesUnaffordableHedge(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.DefaultConfig()
+	cfg.MovePct = 0.10
+
+	// 15 USDC pays for 20 shares at 0.40, but not for hedging them up to 0.95 a pair
+	mockExc := exchange.NewMockExchange()
+	mockExc.Wallet.Collateral = exchange.AmountFromFloat(15)
+	bot := NewBot(cfg, mockExc)
+
+	dump(ctx, bot, mockExc)
+	if bot.state != StateDone {
+		t.Errorf("Expected the cycle to be aborted, got state %v", bot.state)
+	}
+	if usdc, _ := mockExc.GetCollateralBalance(ctx, cfg.MarketID); usdc.Balance != exchange.AmountFromFloat(15) {
+		t.Errorf("Expected no order to fill, balance is %s", usdc.Balance)
+	}
+
+	// With enough for both legs it trades
+	mockExc = exchange.NewMockExchange()
+	mockExc.Wallet.Collateral = exchange.AmountFromFloat(19)
+	bot = NewBot(cfg, mockExc)
+
+	dump(ctx, bot, mockExc)
+	if bot.state != StateLeg1Bought {
+		t.Errorf("Expected state Leg1Bought, got %v", bot.state)
+	}
+}
+
+func TestBotHedgesDustAtMinimumSize(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.DefaultConfig()
+	cfg.MovePct = 0.10
+	cfg.SumTarget = 0.96
+
+	mockExc := exchange.NewMockExchange()
+	bot := NewBot(cfg, mockExc)
+	dump(ctx, bot, mockExc)
+
+	// Only 17 of the 20 hedge shares are offered; the rest is pulled at the timeout
+	mockExc.Depth = 17
+	bot.RunTick(ctx)
+	mockExc.AdvanceTime(cfg.FillTimeout + time.Second)
+	bot.RunTick(ctx)
+	if bot.state != StateLeg1Bought || bot.hedgedShares != 17 {
+		t.Fatalf("Expected 17 shares hedged, got %v in state %v", bot.hedgedShares, bot.state)
+	}
+
+	// The 3 shares left are below the minimum of 5: the hedge is rounded up
+	bot.RunTick(ctx)
+	if bot.state != StateDone {
+		t.Fatalf("Expected the cycle to complete, got state %v", bot.state)
+	}
+	if down := bot.Portfolio().Position(cfg.MarketID, exchange.SideDown); down.Shares != exchange.AmountFromFloat(22) {
+		t.Errorf("Expected 22 DOWN shares, got %s", down.Shares)
+	}
+}
+
+func TestBotTimesFillsOnTheExchangeClock(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.DefaultConfig()
+	cfg.MovePct = 0.10
+	cfg.Leg1OrderType = "GTC"
+
+	mockExc := &restingExchange{exchange.NewMockExchange()}
+	bot := NewBot(cfg, mockExc)
+
+	// Pushed tickers carry a server clock an hour behind ours
+	skewed := func(up, down float64, at time.Duration) *exchange.Ticker {
+		return &exchange.Ticker{MarketID: cfg.MarketID, PriceUp: up, PriceDown: down, Timestamp: mockExc.Time.Add(at - time.Hour)}
+	}
+	mockExc.SetPrice(0.40, 0.55)
+	bot.HandleTicker(ctx, skewed(0.50, 0.50, 0))
+	bot.HandleTicker(ctx, skewed(0.50, 0.50, 3*time.Second))
+	bot.HandleTicker(ctx, skewed(0.40, 0.55, 4*time.Second))
+	if bot.state != StateLeg1Pending {
+		t.Fatalf("Expected state Leg1Pending, got %v", bot.state)
+	}
+
+	// The skew does not count as waiting time
+	bot.pollPendingOrder(ctx)
+	if bot.state != StateLeg1Pending {
+		t.Errorf("Expected the order to keep working, got state %v", bot.state)
+	}
+}
+
+func TestBotBooksFillsByTrade(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.DefaultConfig()
+	cfg.MovePct = 0.10
+	cfg.SumTarget = 0.96
+
+	mockExc := exchange.NewMockExchange()
+	bot := NewBot(cfg, mockExc)
+	dump(ctx, bot, mockExc)
+	bot.RunTick(ctx)
+	if bot.state != StateDone {
+		t.Fatalf("Expected state Done, got %v", bot.state)
+	}
+
+	// Loading the account's trades finds the very fills the bot booked
+	p := bot.Portfolio()
+	if err := p.Load(ctx, mockExc, cfg.MarketID); err != nil {
+		t.Fatal(err)
+	}
+	up, down := p.Position(cfg.MarketID, exchange.SideUp), p.Position(cfg.MarketID, exchange.SideDown)
+	if up.Shares != exchange.AmountFromFloat(20) || down.Shares != exchange.AmountFromFloat(20) {
+		t.Errorf("Expected 20 shares of each side, got %s UP and %s DOWN", up.Shares, down.Shares)
+	}
+}
+
+// laggingTrades hides the account's trades, or fails to list them, and reports
+// some as failed
+type laggingTrades struct {
+	*exchange.MockExchange
+	hide   bool
+	err    error
+	failed map[string]bool // By trade id
+}
+
+func (l *laggingTrades) GetTrades(ctx context.Context, marketID string) ([]*exchange.Trade, error) {
+	if l.err != nil {
+		return nil, l.err
+	}
+	if l.hide {
+		return nil, nil
+	}
+	trades, err := l.MockExchange.GetTrades(ctx, marketID)
+	for _, t := range trades {
+		if l.failed[t.ID] {
+			t.Status = exchange.TradeFailed
+		}
+	}
+	return trades, err
+}
+
+func TestBotBooksLateTradesOnce(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.DefaultConfig()
+	cfg.MovePct = 0.10
+	cfg.SumTarget = 0.90 // No hedge
+
+	for _, lag := range []*laggingTrades{{hide: true}, {err: errors.New("503 service unavailable")}} {
+		lag.MockExchange = exchange.NewMockExchange()
+		bot := NewBot(cfg, lag)
+		dump(ctx, bot, lag.MockExchange)
+		if bot.state != StateLeg1Bought {
+			t.Fatalf("Expected state Leg1Bought, got %v", bot.state)
+		}
+
+		// Nothing is booked before the exchange lists the trade
+		p := bot.Portfolio()
+		if up := p.Position(cfg.MarketID, exchange.SideUp); up.Shares != 0 {
+			t.Errorf("Expected no shares booked before the trade is listed, got %s", up.Shares)
+		}
+
+		// Then it is booked once, however it is seen again
+		lag.hide, lag.err = false, nil
+		bot.RunTick(ctx)
+		bot.RunTick(ctx)
+		if err := p.Load(ctx, lag, cfg.MarketID); err != nil {
+			t.Fatal(err)
+		}
+		if up := p.Position(cfg.MarketID, exchange.SideUp); up.Shares != exchange.AmountFromFloat(20) {
+			t.Errorf("Expected the 20 shares booked once, got %s", up.Shares)
+		}
+	}
+}
+
+func TestBotTakesBackListedFailedTrades(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.DefaultConfig()
+	cfg.MovePct = 0.10
+	cfg.SumTarget = 0.90 // No hedge
+
+	// Leg 1 is listed as failed by the time the bot books it
+	lag := &laggingTrades{MockExchange: exchange.NewMockExchange(), hide: true}
+	bot := NewBot(cfg, lag)
+	dump(ctx, bot, lag.MockExchange)
+	trades, _ := lag.MockExchange.GetTrades(ctx, cfg.MarketID)
+	if len(trades) != 1 {
+		t.Fatalf("Expected one trade, got %d", len(trades))
+	}
+	lag.hide, lag.failed = false, map[string]bool{trades[0].ID: true}
+	lag.AdvanceTime(time.Minute) // Past the dump, so leg 1 is not entered again
+	bot.RunTick(ctx)
+	bot.RunTick(ctx)
+
+	if bot.state != StateWatching || bot.leg1Shares != 0 {
+		t.Errorf("Expected the failed leg 1 to be taken back, got %v shares in state %v", bot.leg1Shares, bot.state)
+	}
+	if up := bot.Portfolio().Position(cfg.MarketID, exchange.SideUp); up.Shares != 0 {
+		t.Errorf("Expected no shares booked, got %s", up.Shares)
+	}
+	if c := bot.Cycles()[0]; c.Shares[exchange.SideUp] != 0 || c.Cost != 0 {
+		t.Errorf("Expected the cycle emptied, got %+v", c)
+	}
+}
+
+func TestBotNamesOrdersAfterCycles(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.DefaultConfig()
+	cfg.MovePct = 0.10
+	cfg.SumTarget = 0.96
+
+	mockExc := exchange.NewMockExchange()
+	bot := NewBot(cfg, mockExc)
+	dump(ctx, bot, mockExc)
+	bot.RunTick(ctx)
+	if bot.state != StateDone {
+		t.Fatalf("Expected state Done, got %v", bot.state)
+	}
+
+	var clientIDs []string
+	for id := range bot.cycle.orders {
+		order, err := mockExc.GetOrder(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		clientIDs = append(clientIDs, order.ClientID)
+	}
+	slices.Sort(clientIDs)
+	if want := []string{"cycle-1-leg-1-1", "cycle-1-leg-2-2"}; !slices.Equal(clientIDs, want) {
+		t.Errorf("Expected client ids %v, got %v", want, clientIDs)
+	}
+}
+
+func TestBotRollsBackFailedTrades(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.DefaultConfig()
+	cfg.MovePct = 0.10
+	cfg.SumTarget = 0.96
+
+	mockExc := exchange.NewMockExchange()
+	bot := NewBot(cfg, mockExc)
+	dump(ctx, bot, mockExc)
+	bot.RunTick(ctx)
+	if bot.state != StateDone {
+		t.Fatalf("Expected state Done, got %v", bot.state)
+	}
+
+	// The hedge fill (second trade, of the second order) fails to settle on chain. The
+	// event is sent twice, without the side of an unknown token.
+	trades, _ := mockExc.GetTrades(ctx, cfg.MarketID)
+	hedge := trades[1]
+	for i := 0; i < 2; i++ {
+		bot.HandleUserEvent(ctx, &exchange.UserEvent{
+			Type: exchange.UserEventTrade, MarketID: hedge.MarketID, OrderID: hedge.OrderID,
+			Direction: hedge.Direction, Price: hedge.Price, TradeID: hedge.ID, TradeStatus: exchange.TradeFailed, Size: hedge.Size,
+		})
+	}
+
+	if bot.state != StateLeg1Bought || bot.hedgedShares != 0 {
+		t.Errorf("Expected the hedge to be needed again, got %v hedged in state %v", bot.hedgedShares, bot.state)
+	}
+	if down := bot.Portfolio().Position(cfg.MarketID, exchange.SideDown); down.Shares != 0 {
+		t.Errorf("Expected the failed DOWN shares to be taken back, got %s", down.Shares)
+	}
+	c := bot.Cycles()[0]
+	if c.Shares[exchange.SideUp] != exchange.AmountFromFloat(20) || c.Shares[exchange.SideDown] != 0 || c.Cost != exchange.AmountFromFloat(8) {
+		t.Errorf("Expected the cycle to keep leg 1 only, got %+v", c)
+	}
+	if _, ok := c.Shares[""]; ok {
+		t.Error("Expected no shares booked without a side")
+	}
+}
+
+func TestBotCompletesWithoutLeg1Shares(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.DefaultConfig()
+	mockExc := exchange.NewMockExchange()
+	bot := NewBot(cfg, mockExc)
+
+	// Leg 1 was taken back while the hedge was resting, then the hedge fills
+	bot.state = StateLeg2Pending
+	bot.leg1Side = exchange.SideUp
+	bot.onLeg2Filled(ctx, &exchange.Order{ID: "0xhedge", MarketID: cfg.MarketID, Side: exchange.SideDown,
+		Direction: exchange.DirectionBuy, Price: 0.5, Size: 20, SizeMatched: 20, AvgPrice: 0.5, Status: exchange.OrderMatched})
 
 	if bot.state != StateDone {
 		t.Errorf("Expected state Done, got %v", bot.state)
@@ -0,0 +1,92 @@
+package strategy
+
+import (
+	"time"
+
+	"poly/pkg/exchange"
+	"poly/pkg/portfolio"
+)
+
+// Cycle is the record of one dump-and-hedge cycle, kept until its shares are settled
+type Cycle struct {
+	ID       int
+	MarketID string
+	Opened   time.Time
+	Shares   map[exchange.Side]exchange.Amount // Shares bought and still held
+	Cost     exchange.Amount                   // USDC spent on both legs
+	Payout   exchange.Amount                   // USDC returned by merges and redemptions
+	Settled  bool                              // Every share was merged or redeemed
+
+	orders map[string]exchange.Side // Side of the leg orders whose fills are booked, by id
+}
+
+// PnL returns what the cycle made so far: its payouts less its cost
+func (c *Cycle) PnL() exchange.Amount {
+	return c.Payout.Sub(c.Cost)
+}
+
+// merge accounts for pairs of the cycle merged back into $1 each
+func (c *Cycle) merge(pairs exchange.Amount) {
+	c.Shares[exchange.SideUp] = c.Shares[exchange.SideUp].Sub(pairs)
+	c.Shares[exchange.SideDown] = c.Shares[exchange.SideDown].Sub(pairs)
+	c.Payout = c.Payout.Add(pairs)
+	c.Settled = c.Shares[exchange.SideUp] <= 0 && c.Shares[exchange.SideDown] <= 0
+}
+
+// redeem accounts for the shares left redeemed at the payouts of a resolution
+func (c *Cycle) redeem(payouts portfolio.Marks) exchange.Amount {
+	var paid exchange.Amount
+	for side, shares := range c.Shares {
+		paid = paid.Add(shares.Mul(payouts.Price(side), exchange.RoundDown))
+		c.Shares[side] = 0
+	}
+	c.Payout = c.Payout.Add(paid)
+	c.Settled = true
+	return paid
+}
+
+// writeOff settles the cycle without payout for the shares it has left
+func (c *Cycle) writeOff() {
+	for side := range c.Shares {
+		c.Shares[side] = 0
+	}
+	c.Settled = true
+}
+
+// openCycle starts the record of the current cycle on its first fill
+func (b *Bot) openCycle(now time.Time) {
+	b.cycle = &Cycle{
+		ID:       len(b.cycles) + 1,
+		MarketID: b.marketID,
+		Opened:   now,
+		Shares:   make(map[exchange.Side]exchange.Amount),
+		orders:   make(map[string]exchange.Side),
+	}
+	b.cycles = append(b.cycles, b.cycle)
+}
+
+// Cycles returns the records of every cycle that traded, oldest first
+func (b *Bot) Cycles() []Cycle {
+	cycles := make([]Cycle, len(b.cycles))
+	for i, c := range b.cycles {
+		cycles[i] = *c
+		cycles[i].Shares = make(map[exchange.Side]exchange.Amount, len(c.Shares))
+		for side, shares := range c.Shares {
+			cycles[i].Shares[side] = shares
+		}
+	}
+	return cycles
+}
+
+// heldMarkets lists the markets of the cycles with shares left, without repeats
+func (b *Bot) heldMarkets() []string {
+	var markets []string
+	seen := make(map[string]bool)
+	for _, c := range b.cycles {
+		if !c.Settled && !seen[c.MarketID] {
+			seen[c.MarketID] = true
+			markets = append(markets, c.MarketID)
+		}
+	}
+	return markets
+}
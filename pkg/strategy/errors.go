@@ -0,0 +1,66 @@
+package strategy
+
+import (
+	"errors"
+	"log"
+
+	"poly/pkg/exchange"
+)
+
+// reaction is how the bot responds to a failed exchange call
+type reaction int
+
+const (
+	reactRetry      reaction = iota // Transient: try again on a later tick
+	reactAbortCycle                 // The cycle cannot go on: sit out the rest of the round
+	reactHalt                       // Trading is impossible: stop the bot
+)
+
+// reactionTo classifies an error by its exchange failure kind. Rate limits, network
+// errors, unfilled FOK/FAK orders and stale tick sizes clear up by themselves.
+func reactionTo(err error) reaction {
+	switch {
+	case errors.Is(err, exchange.ErrAuth):
+		return reactHalt
+	case errors.Is(err, exchange.ErrInsufficientBalance),
+		errors.Is(err, exchange.ErrOrderRejected),
+		errors.Is(err, exchange.ErrMarketClosed):
+		return reactAbortCycle
+	}
+	return reactRetry
+}
+
+// handleError reports err and returns the bot's reaction, halting it when needed
+func (b *Bot) handleError(err error) reaction {
+	b.reportError(err)
+	r := reactionTo(err)
+	if r == reactHalt && b.halted == nil {
+		log.Printf("HALTING: %v", err)
+		b.halted = err
+	}
+	return r
+}
+
+// Halted returns the error that stopped the bot, nil while it is running
+func (b *Bot) Halted() error {
+	return b.halted
+}
+
+// legFailed handles an error that kept a leg from being placed
+func (b *Bot) legFailed(err error) {
+	if b.handleError(err) == reactAbortCycle {
+		b.abortCycle(err)
+	}
+}
+
+// abortCycle gives up on the current cycle until the next round
+func (b *Bot) abortCycle(err error) {
+	if unhedged := b.leg1Shares - b.hedgedShares; unhedged > 0 {
+		log.Printf("WARNING: cycle aborted with %.2f unhedged %s shares: %v", unhedged, b.leg1Side, err)
+	} else {
+		log.Printf("Cycle aborted: %v", err)
+	}
+	b.pendingOrder = nil
+	b.pendingUnknown = false
+	b.state = StateDone
+}
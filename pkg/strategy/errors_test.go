@@ -0,0 +1,145 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"poly/pkg/config"
+	"poly/pkg/exchange"
+)
+
+// rejectingExchange fails every placement with err
+type rejectingExchange struct {
+	*exchange.MockExchange
+	err error
+}
+
+func (r *rejectingExchange) PlaceOrder(ctx context.Context, marketID string, side exchange.Side, dir exchange.Direction, size float64, price float64, opts exchange.OrderOptions) (*exchange.Order, error) {
+	return nil, r.err
+}
+
+// dump drives the mock through an UP dump that triggers leg 1
+func dump(ctx context.Context, bot *Bot, m *exchange.MockExchange) {
+	m.SetPrice(0.50, 0.50)
+	bot.RunTick(ctx)
+	m.AdvanceTime(3 * time.Second)
+	bot.RunTick(ctx)
+	m.AdvanceTime(1 * time.Second)
+	m.SetPrice(0.40, 0.55)
+	bot.RunTick(ctx)
+}
+
+func TestBotReactsToOrderErrors(t *testing.T) {
+	ctx := context.Background()
+	cases := []struct {
+		err  error
+		want State
+	}{
+		{fmt.Errorf("%w: no liquidity", exchange.ErrNotFilled), StateWatching},
+		{&exchange.NetworkError{Err: errors.New("connection reset")}, StateWatching},
+		{&exchange.APIError{StatusCode: 429}, StateWatching},
+		{&exchange.APIError{StatusCode: 400, Message: "not enough balance / allowance"}, StateDone},
+		{&exchange.APIError{StatusCode: 400, Message: "MARKET_NOT_READY"}, StateDone},
+	}
+	for _, tc := range cases {
+		cfg := config.DefaultConfig()
+		cfg.MovePct = 0.10
+		mockExc := exchange.NewMockExchange()
+		bot := NewBot(cfg, &rejectingExchange{mockExc, tc.err})
+
+		dump(ctx, bot, mockExc)
+		if bot.state != tc.want {
+			t.Errorf("%v: expected state %v, got %v", tc.err, tc.want, bot.state)
+		}
+		if bot.Halted() != nil {
+			t.Errorf("%v: bot should keep running", tc.err)
+		}
+	}
+}
+
+func TestBotHaltsOnAuthFailure(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.PollInterval = time.Millisecond
+	cfg.MovePct = 0.10
+
+	mockExc := exchange.NewMockExchange()
+	bot := NewBot(cfg, &rejectingExchange{mockExc, &exchange.APIError{StatusCode: 401, Message: "Unauthorized/Invalid api key"}})
+	dump(context.Background(), bot, mockExc)
+	if !errors.Is(bot.Halted(), exchange.ErrAuth) {
+		t.Fatalf("expected the bot to halt on ErrAuth, got %v", bot.Halted())
+	}
+
+	// Run stops right away instead of trading on
+	done := make(chan error)
+	go func() { done <- bot.Run(context.Background()) }()
+	select {
+	case err := <-done:
+		if !errors.Is(err, exchange.ErrAuth) {
+			t.Errorf("Run returned %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not stop on a halted bot")
+	}
+}
+
+// lostReplyExchange loses the reply to every placement; reach decides whether the
+// order got to the book anyway
+type lostReplyExchange struct {
+	*exchange.MockExchange
+	reach  bool
+	placed int
+}
+
+func (l *lostReplyExchange) PlaceOrder(ctx context.Context, marketID string, side exchange.Side, dir exchange.Direction, size float64, price float64, opts exchange.OrderOptions) (*exchange.Order, error) {
+	l.placed++
+	id := fmt.Sprintf("0xlost-%d", l.placed)
+	if l.reach {
+		order, err := l.MockExchange.PlaceOrder(ctx, marketID, side, dir, size, price, opts)
+		if err != nil {
+			return nil, err
+		}
+		id = order.ID
+	}
+	return nil, &exchange.OrderUnknownError{ID: id, Err: errors.New("502 bad gateway")}
+}
+
+func TestBotFollowsOrdersInUnknownState(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.DefaultConfig()
+	cfg.MovePct = 0.10
+	cfg.SumTarget = 0.90 // No hedge
+
+	// The order went through: it is picked up instead of being placed again
+	mockExc := exchange.NewMockExchange()
+	lost := &lostReplyExchange{MockExchange: mockExc, reach: true}
+	bot := NewBot(cfg, lost)
+	dump(ctx, bot, mockExc)
+	if bot.state != StateLeg1Pending {
+		t.Fatalf("Expected state Leg1Pending, got %v", bot.state)
+	}
+	bot.RunTick(ctx)
+	if bot.state != StateLeg1Bought || bot.leg1Shares != 20 {
+		t.Fatalf("Expected the 20 shares of the lost order, got %v in state %v", bot.leg1Shares, bot.state)
+	}
+	if lost.placed != 1 {
+		t.Errorf("Expected a single placement, got %d", lost.placed)
+	}
+
+	// The order never got there: the bot gives up on it after the fill timeout
+	mockExc = exchange.NewMockExchange()
+	lost = &lostReplyExchange{MockExchange: mockExc}
+	bot = NewBot(cfg, lost)
+	dump(ctx, bot, mockExc)
+	bot.RunTick(ctx)
+	if bot.state != StateLeg1Pending || lost.placed != 1 {
+		t.Fatalf("Expected to keep waiting for the order, got state %v after %d placements", bot.state, lost.placed)
+	}
+	mockExc.AdvanceTime(cfg.FillTimeout)
+	bot.RunTick(ctx)
+	if bot.state != StateWatching {
+		t.Errorf("Expected state Watching, got %v", bot.state)
+	}
+}
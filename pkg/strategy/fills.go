@@ -0,0 +1,309 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"poly/pkg/exchange"
+	"poly/pkg/portfolio"
+)
+
+// orderOptions builds the placement options of a leg order for a configured order type
+func (b *Bot) orderOptions(orderType string, leg int, now time.Time) exchange.OrderOptions {
+	opts := exchange.OrderOptions{Type: exchange.OrderType(orderType), ClientID: b.clientID(leg)}
+	if opts.Type == exchange.OrderTypeGTD {
+		opts.Expiration = now.Add(b.cfg.OrderTTL)
+	}
+	return opts
+}
+
+// clientID names a leg order after its cycle, e.g. cycle-3-leg-2-7 for the 7th order
+// the bot placed, so exchange orders can be traced back to the cycle they belong to
+func (b *Bot) clientID(leg int) string {
+	cycle := len(b.cycles) + 1 // Leg 1 opens the next cycle once it fills
+	if leg == 2 && b.cycle != nil {
+		cycle = b.cycle.ID
+	}
+	b.ordersPlaced++
+	return fmt.Sprintf("cycle-%d-leg-%d-%d", cycle, leg, b.ordersPlaced)
+}
+
+// trackOrder waits for a leg order to fill, acting right away on the placement status.
+// The fill timeout runs on the exchange clock, never on ticker or event timestamps.
+func (b *Bot) trackOrder(ctx context.Context, order *exchange.Order, pending State) {
+	b.pendingOrder = order
+	b.pendingSince = b.exchange.CurrentTime()
+	b.state = pending
+	log.Printf("Order %s (%s) placed (%s)", order.ID, order.ClientID, order.Status)
+
+	b.handleOrderUpdate(ctx, order)
+}
+
+// trackUnknownOrder follows an order whose placement failed ambiguously, so the leg is
+// not placed a second time while the first order may be live. It reports whether err
+// was such a failure.
+func (b *Bot) trackUnknownOrder(ctx context.Context, err error, placed *exchange.Order, pending State) bool {
+	var unknown *exchange.OrderUnknownError
+	if !errors.As(err, &unknown) {
+		return false
+	}
+	b.reportError(err)
+	placed.ID = unknown.ID
+	b.pendingUnknown = true
+	b.trackOrder(ctx, placed, pending)
+	return true
+}
+
+// pollPendingOrder refreshes the pending order from the exchange
+func (b *Bot) pollPendingOrder(ctx context.Context) {
+	callCtx, cancel := b.withTimeout(ctx, b.cfg.RequestTimeout)
+	order, err := b.exchange.GetOrder(callCtx, b.pendingOrder.ID)
+	cancel()
+	if err != nil {
+		if b.pendingUnknown && errors.Is(err, exchange.ErrNotFound) &&
+			b.exchange.CurrentTime().Sub(b.pendingSince) >= b.cfg.FillTimeout {
+			// Still unknown to the exchange long after the post: it never got through
+			log.Printf("Order %s never reached the book", b.pendingOrder.ID)
+			never := *b.pendingOrder
+			never.Status = exchange.OrderCancelled
+			b.handleOrderUpdate(ctx, &never)
+			return
+		}
+		b.handleError(fmt.Errorf("fetching order %s: %w", b.pendingOrder.ID, err))
+		return
+	}
+	b.pendingUnknown = false
+	b.handleOrderUpdate(ctx, order)
+}
+
+// HandleUserEvent reacts to a pushed fill or order update instead of waiting for the next poll
+func (b *Bot) HandleUserEvent(ctx context.Context, ev *exchange.UserEvent) {
+	if b.halted != nil {
+		return
+	}
+	if ev.Type == exchange.UserEventTrade && ev.TradeStatus == exchange.TradeFailed {
+		log.Printf("WARNING: trade %s of order %s failed on chain, the fill did not happen", ev.TradeID, ev.OrderID)
+		b.rollbackFill(&exchange.Trade{
+			ID:        ev.TradeID,
+			MarketID:  ev.MarketID,
+			OrderID:   ev.OrderID,
+			Side:      ev.Side,
+			Direction: ev.Direction,
+			Price:     ev.Price,
+			Size:      ev.Size,
+			Status:    exchange.TradeFailed,
+			Timestamp: ev.Timestamp,
+		})
+	}
+	if b.pendingOrder == nil || ev.OrderID != b.pendingOrder.ID {
+		return
+	}
+
+	// Events only trigger a refresh: the order itself carries the exact fill price
+	switch ev.Type {
+	case exchange.UserEventOrder:
+		if ev.OrderStatus.IsFinal() {
+			b.pollPendingOrder(ctx)
+		}
+	case exchange.UserEventTrade:
+		if ev.TradeStatus == exchange.TradeMatched || ev.TradeStatus == exchange.TradeFailed {
+			b.pollPendingOrder(ctx)
+		}
+	}
+}
+
+// handleOrderUpdate advances the state machine once the pending order is filled,
+// cancelled or stale. Only confirmed fills move the cycle forward.
+func (b *Bot) handleOrderUpdate(ctx context.Context, order *exchange.Order) {
+	keepSignedAmounts(order, b.pendingOrder)
+	b.pendingOrder = order
+
+	if !order.Status.IsFinal() {
+		if b.exchange.CurrentTime().Sub(b.pendingSince) < b.cfg.FillTimeout {
+			return
+		}
+
+		log.Printf("Order %s not filled after %v, cancelling", order.ID, b.cfg.FillTimeout)
+		callCtx, cancel := b.withTimeout(ctx, b.cfg.RequestTimeout)
+		if err := b.exchange.CancelOrder(callCtx, order.ID); err != nil {
+			b.handleError(fmt.Errorf("cancelling order %s: %w", order.ID, err))
+		}
+		cancel()
+		// The order may have filled in the meantime; pick up the final state
+		callCtx, cancel = b.withTimeout(ctx, b.cfg.RequestTimeout)
+		if latest, err := b.exchange.GetOrder(callCtx, order.ID); err == nil {
+			keepSignedAmounts(latest, order)
+			order = latest
+		}
+		cancel()
+		if !order.Status.IsFinal() {
+			return
+		}
+	}
+
+	b.pendingOrder = nil
+	b.pendingUnknown = false
+	filled := order.Status == exchange.OrderMatched || order.SizeMatched > 0
+
+	switch b.state {
+	case StateLeg1Pending:
+		if filled {
+			b.onLeg1Filled(ctx, order)
+		} else {
+			log.Printf("Leg 1 order %s %s without fill, back to watching", order.ID, order.Status)
+			b.leg1Side = ""
+			b.state = StateWatching
+		}
+	case StateLeg2Pending:
+		if filled {
+			b.onLeg2Filled(ctx, order)
+		} else {
+			log.Printf("Hedge order %s %s without fill, waiting for another opportunity", order.ID, order.Status)
+			b.state = StateLeg1Bought
+		}
+	}
+}
+
+// keepSignedAmounts copies the exact signed amounts of the placed order onto a
+// refreshed copy, as order lookups only report the limit price
+func keepSignedAmounts(order, placed *exchange.Order) {
+	if placed == nil || placed.ID != order.ID || order.MakerAmount > 0 {
+		return
+	}
+	order.MakerAmount, order.TakerAmount = placed.MakerAmount, placed.TakerAmount
+}
+
+// recordFill books a leg fill in the cycle, and its trades in the portfolio. Only the
+// trades the exchange lists are booked, under their own ids and statuses, so fills
+// also loaded with Portfolio.Load count once; trades not listed yet are booked on later
+// ticks (see bookTrades). The leg counters must be set first: failed trades are taken
+// back from them as well as from the cycle.
+func (b *Bot) recordFill(ctx context.Context, order *exchange.Order) {
+	if order.MarketID == "" {
+		cp := *order
+		cp.MarketID = b.marketID
+		order = &cp
+	}
+	if b.cycle != nil {
+		b.cycle.orders[order.ID] = order.Side
+		b.cycle.Shares[order.Side] = b.cycle.Shares[order.Side].Add(exchange.AmountFromFloat(filledShares(order)))
+		b.cycle.Cost = b.cycle.Cost.Add(order.Cost())
+	}
+	b.unbooked = append(b.unbooked, order)
+	b.bookTrades(ctx)
+}
+
+// bookTrades books the listed trades of the filled orders not fully booked yet. The
+// trades API may lag the orders, or fail: an order is retried every tick until its
+// trades, failed ones included, cover its fill.
+func (b *Bot) bookTrades(ctx context.Context) {
+	if len(b.unbooked) == 0 {
+		return
+	}
+	listed := make(map[string][]*exchange.Trade) // By market, listed once per call
+	pending := b.unbooked[:0]
+	for _, order := range b.unbooked {
+		trades, ok := listed[order.MarketID]
+		if !ok {
+			callCtx, cancel := b.withTimeout(ctx, b.cfg.RequestTimeout)
+			var err error
+			trades, err = b.exchange.GetTrades(callCtx, order.MarketID)
+			cancel()
+			if err != nil {
+				b.reportError(fmt.Errorf("listing the trades of %s, booking them later: %w", order.MarketID, err))
+			}
+			listed[order.MarketID] = trades
+		}
+
+		var covered exchange.Amount
+		for _, t := range trades {
+			if t.ID == "" || !strings.EqualFold(t.OrderID, order.ID) {
+				continue
+			}
+			if t.Status == exchange.TradeFailed {
+				b.rollbackFill(t)
+			} else {
+				b.portfolio.Apply(t)
+			}
+			covered = covered.Add(exchange.AmountFromFloat(t.Size))
+		}
+		if covered < exchange.AmountFromFloat(filledShares(order)) {
+			pending = append(pending, order)
+		}
+	}
+	b.unbooked = pending
+}
+
+// rollbackFill takes back a booked fill whose trade failed on chain, so the bot
+// neither hedges nor merges shares it never got. Each trade is only taken back once,
+// however often its failure is seen. A pending order is left to the refresh that
+// follows: its fill is only booked once final.
+func (b *Bot) rollbackFill(t *exchange.Trade) {
+	failed := *t
+	failed.Status = exchange.TradeFailed
+	b.portfolio.Apply(&failed)
+
+	key := t.ID + "/" + t.OrderID
+	if b.rolledBack[key] {
+		return
+	}
+	b.rolledBack[key] = true
+
+	// Events may not name the side: the leg order it was booked under does
+	c, side := b.cycleOf(t.OrderID)
+	if c == nil {
+		return
+	}
+	shares := exchange.AmountFromFloat(t.Size)
+	value := exchange.AmountFromFloat(t.Price).Mul(shares, exchange.RoundHalfUp)
+	c.Shares[side] = c.Shares[side].Sub(shares)
+	c.Cost = c.Cost.Sub(value)
+	if c != b.cycle {
+		return // An earlier cycle: its legs are over
+	}
+	hedged := b.hedgedShares >= b.leg1Shares
+	if side == b.leg1Side {
+		b.leg1Shares -= t.Size
+		b.leg1Cost = b.leg1Cost.Sub(value)
+	} else {
+		b.hedgedShares -= t.Size
+		b.leg2Cost = b.leg2Cost.Sub(value)
+	}
+
+	switch {
+	case b.state == StateLeg1Bought && b.leg1Shares <= 0 && b.hedgedShares <= 0:
+		log.Printf("Leg 1 fill failed, back to watching")
+		b.leg1Side = ""
+		b.state = StateWatching
+	case b.state == StateDone && hedged && b.hedgedShares < b.leg1Shares:
+		log.Printf("Hedge fill failed: %.2f / %.2f shares hedged, hedging again", b.hedgedShares, b.leg1Shares)
+		b.state = StateLeg1Bought
+	}
+}
+
+// cycleOf returns the cycle that booked the fill of a leg order, and the order's side
+func (b *Bot) cycleOf(orderID string) (*Cycle, exchange.Side) {
+	for _, c := range b.cycles {
+		if side, ok := c.orders[orderID]; ok {
+			return c, side
+		}
+	}
+	return nil, ""
+}
+
+// Portfolio returns the positions the bot has built
+func (b *Bot) Portfolio() *portfolio.Portfolio {
+	return b.portfolio
+}
+
+// filledShares returns the filled size, treating a matched order without size info as fully filled
+func filledShares(order *exchange.Order) float64 {
+	if order.SizeMatched > 0 {
+		return order.SizeMatched
+	}
+	return order.Size
+}
@@ -0,0 +1,218 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"poly/pkg/exchange"
+)
+
+// RoundSchedule describes a recurring market whose slug ends with the round start
+// in unix seconds, e.g. "btc-updown-15m-1760000400"
+type RoundSchedule struct {
+	SlugPrefix string        // e.g. "btc-updown-15m"
+	Duration   time.Duration // e.g. 15 * time.Minute
+}
+
+// RoundStart returns the start of the round running at t
+func (s RoundSchedule) RoundStart(t time.Time) time.Time {
+	d := int64(s.Duration / time.Second)
+	return time.Unix(t.Unix()/d*d, 0)
+}
+
+// Slug returns the slug of the round starting at start
+func (s RoundSchedule) Slug(start time.Time) string {
+	return fmt.Sprintf("%s-%d", s.SlugPrefix, start.Unix())
+}
+
+// Round is one market of a recurring schedule
+type Round struct {
+	Slug   string
+	Market exchange.Market
+	Start  time.Time
+	End    time.Time
+}
+
+// Contains reports whether t falls within the round
+func (r *Round) Contains(t time.Time) bool {
+	return !t.Before(r.Start) && t.Before(r.End)
+}
+
+// MarketFinder looks markets up by slug, e.g. exchange.GammaClient
+type MarketFinder interface {
+	MarketBySlug(ctx context.Context, slug string) (*exchange.GammaMarket, error)
+}
+
+// RoundScheduler discovers the current and next round of a schedule
+type RoundScheduler struct {
+	schedule RoundSchedule
+	finder   MarketFinder
+
+	current *Round
+	next    *Round // Prefetched so the rollover does not wait on the API
+}
+
+func NewRoundScheduler(schedule RoundSchedule, finder MarketFinder) *RoundScheduler {
+	return &RoundScheduler{schedule: schedule, finder: finder}
+}
+
+// Round returns the round running at now, looking it up when the previous one ended
+func (s *RoundScheduler) Round(ctx context.Context, now time.Time) (*Round, error) {
+	if s.current == nil || !s.current.Contains(now) {
+		if s.next != nil && s.next.Contains(now) {
+			s.current = s.next
+		} else {
+			r, err := s.lookup(ctx, s.schedule.RoundStart(now))
+			if err != nil {
+				return nil, err
+			}
+			s.current = r
+		}
+		s.next = nil
+	}
+
+	if s.next == nil {
+		// Best effort: the next market is usually listed well in advance
+		if r, err := s.lookup(ctx, s.current.End); err == nil {
+			s.next = r
+		}
+	}
+	return s.current, nil
+}
+
+// lookup fetches the round starting at start
+func (s *RoundScheduler) lookup(ctx context.Context, start time.Time) (*Round, error) {
+	slug := s.schedule.Slug(start)
+	gm, err := s.finder.MarketBySlug(ctx, slug)
+	if err != nil {
+		return nil, fmt.Errorf("looking up round %s: %w", slug, err)
+	}
+	m, err := gm.Market()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Round{Slug: slug, Market: m, Start: start, End: gm.EndTime}
+	if r.End.IsZero() || !r.End.After(start) {
+		r.End = start.Add(s.schedule.Duration)
+	}
+	return r, nil
+}
+
+// SetRoundScheduler makes the bot follow a recurring market, rolling over to the
+// next round (market, tokens and price history) when the current one ends
+func (b *Bot) SetRoundScheduler(s *RoundScheduler) {
+	b.rounds = s
+}
+
+// marketRegistry is implemented by exchanges that need to learn the outcome tokens
+// of a market, e.g. exchange.PolymarketClient
+type marketRegistry interface {
+	RegisterMarket(m exchange.Market)
+}
+
+// streamSubscriber is implemented by the WebSocket streams
+type streamSubscriber interface {
+	Subscribe(m exchange.Market)
+	Unsubscribe(m exchange.Market)
+}
+
+// Backoff of the round lookups after a failure, doubling up to roundRetryMax
+const (
+	roundRetryMin = time.Second
+	roundRetryMax = 30 * time.Second
+)
+
+// updateRound switches to the round running at now when the current one ended
+func (b *Bot) updateRound(ctx context.Context, now time.Time) {
+	if b.rounds == nil || (b.round != nil && b.round.Contains(now)) {
+		return
+	}
+
+	// Gamma may not list the round yet: do not hit it on every tick meanwhile
+	if clock := b.exchange.CurrentTime(); clock.Before(b.roundRetryAt) {
+		return
+	}
+
+	callCtx, cancel := b.withTimeout(ctx, b.cfg.RequestTimeout)
+	r, err := b.rounds.Round(callCtx, now)
+	cancel()
+	if err != nil {
+		if roundRetryMin<<b.roundFailures < roundRetryMax {
+			b.roundFailures++
+		}
+		delay := min(roundRetryMin<<(b.roundFailures-1), roundRetryMax)
+		b.roundRetryAt = b.exchange.CurrentTime().Add(delay)
+		b.handleError(fmt.Errorf("%w (retrying in %v)", err, delay))
+		return
+	}
+	b.roundFailures = 0
+	b.roundRetryAt = time.Time{}
+	if b.round != nil && r.Slug == b.round.Slug {
+		return
+	}
+	b.startRound(ctx, r)
+}
+
+// startRound resets the cycle for a new round of the schedule
+func (b *Bot) startRound(ctx context.Context, r *Round) {
+	log.Printf("--- New round %s (%s - %s) ---", r.Slug, r.Start.Format(time.Kitchen), r.End.Format(time.Kitchen))
+
+	if b.pendingOrder != nil {
+		b.closePendingOrder(ctx)
+	}
+	if b.state == StateLeg1Bought {
+		log.Printf("WARNING: round ended with %.2f unhedged %s shares of %s", b.leg1Shares-b.hedgedShares, b.leg1Side, b.marketID)
+	}
+
+	if reg, ok := b.exchange.(marketRegistry); ok {
+		reg.RegisterMarket(r.Market)
+	}
+	for _, src := range []interface{}{b.tickerSource, b.eventSource} {
+		if sub, ok := src.(streamSubscriber); ok {
+			if b.round != nil {
+				sub.Unsubscribe(b.round.Market)
+			}
+			sub.Subscribe(r.Market)
+		}
+	}
+
+	b.round = r
+	b.marketID = r.Market.ID
+	b.resetBuffers() // Prices of the old tokens mean nothing for the new ones
+	b.ResetCycle()
+	b.roundStartTime = r.Start
+}
+
+// closePendingOrder cancels the pending order of the ending round and books what it
+// filled before the cancel took effect
+func (b *Bot) closePendingOrder(ctx context.Context) {
+	placed := b.pendingOrder
+	callCtx, cancel := b.withTimeout(ctx, b.cfg.RequestTimeout)
+	if err := b.exchange.CancelOrder(callCtx, placed.ID); err != nil {
+		b.handleError(fmt.Errorf("cancelling order %s at rollover: %w", placed.ID, err))
+	}
+	cancel()
+
+	callCtx, cancel = b.withTimeout(ctx, b.cfg.RequestTimeout)
+	order, err := b.exchange.GetOrder(callCtx, placed.ID)
+	cancel()
+	if err != nil {
+		b.handleError(fmt.Errorf("fetching order %s at rollover, its fills are not booked: %w", placed.ID, err))
+		return
+	}
+	keepSignedAmounts(order, placed)
+	b.pendingOrder = nil
+	if order.Status != exchange.OrderMatched && order.SizeMatched == 0 {
+		return
+	}
+
+	switch b.state {
+	case StateLeg1Pending:
+		b.onLeg1Filled(ctx, order)
+	case StateLeg2Pending:
+		b.onLeg2Filled(ctx, order)
+	}
+}
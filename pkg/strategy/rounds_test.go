@@ -0,0 +1,207 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"poly/pkg/config"
+	"poly/pkg/exchange"
+)
+
+// newFakeGamma serves one up/down market per 15 minute round starting at first
+func newFakeGamma(t *testing.T, first int64, rounds int, lookups *int32) *exchange.GammaClient {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(lookups, 1)
+		var start int64
+		fmt.Sscanf(strings.TrimPrefix(r.URL.Query().Get("slug"), "btc-updown-15m-"), "%d", &start)
+		if start < first || start >= first+int64(rounds)*900 {
+			w.Write([]byte(`[]`))
+			return
+		}
+		fmt.Fprintf(w, `[{"conditionId": "0xround-%d", "slug": "btc-updown-15m-%d",
+			"outcomes": "[\"Up\", \"Down\"]", "clobTokenIds": "[\"up-%d\", \"down-%d\"]",
+			"endDate": %q, "active": true, "acceptingOrders": true}]`,
+			start, start, start, start, time.Unix(start+900, 0).UTC().Format(time.RFC3339))
+	}))
+	t.Cleanup(srv.Close)
+
+	g := exchange.NewGammaClient()
+	g.BaseURL = srv.URL
+	return g
+}
+
+func TestRoundScheduler(t *testing.T) {
+	ctx := context.Background()
+	var lookups int32
+	s := NewRoundScheduler(RoundSchedule{SlugPrefix: "btc-updown-15m", Duration: 15 * time.Minute},
+		newFakeGamma(t, 1760000400, 2, &lookups))
+
+	r, err := s.Round(ctx, time.Unix(1760000400+100, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Slug != "btc-updown-15m-1760000400" || r.Market.TokenUp != "up-1760000400" || r.End.Unix() != 1760001300 {
+		t.Errorf("unexpected round %+v", r)
+	}
+
+	// The next round was prefetched: rolling over needs no lookup
+	before := atomic.LoadInt32(&lookups)
+	r, err = s.Round(ctx, time.Unix(1760001300, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Market.ID != "0xround-1760001300" {
+		t.Errorf("expected the second round, got %s", r.Market.ID)
+	}
+	if got := atomic.LoadInt32(&lookups) - before; got != 1 {
+		t.Errorf("expected only the third round to be looked up, got %d lookups", got)
+	}
+
+	// No market listed for the third round
+	if _, err := s.Round(ctx, time.Unix(1760002200, 0)); err == nil {
+		t.Error("expected an error for an unlisted round")
+	}
+}
+
+func TestBotRollsOverRounds(t *testing.T) {
+	ctx := context.Background()
+	var lookups int32
+	cfg := config.DefaultConfig()
+	cfg.MovePct = 0.10
+	cfg.SumTarget = 0.90 // Keep leg 1 open across the rollover
+
+	mockExc := exchange.NewMockExchange()
+	mockExc.Time = time.Unix(1760000400+30, 0)
+	bot := NewBot(cfg, mockExc)
+	bot.SetRoundScheduler(NewRoundScheduler(RoundSchedule{SlugPrefix: "btc-updown-15m", Duration: 15 * time.Minute},
+		newFakeGamma(t, 1760000400, 2, &lookups)))
+
+	mockExc.SetPrice(0.50, 0.50)
+	bot.RunTick(ctx)
+	if bot.marketID != "0xround-1760000400" || bot.roundStartTime.Unix() != 1760000400 {
+		t.Fatalf("expected the first round, got %s starting %v", bot.marketID, bot.roundStartTime)
+	}
+
+	mockExc.AdvanceTime(3 * time.Second)
+	bot.RunTick(ctx)
+	mockExc.AdvanceTime(1 * time.Second)
+	mockExc.SetPrice(0.40, 0.55)
+	bot.RunTick(ctx)
+	if bot.state != StateLeg1Bought {
+		t.Fatalf("Expected state Leg1Bought, got %v", bot.state)
+	}
+
+	// The round ends: the bot moves to the next market with a fresh cycle
+	mockExc.Time = time.Unix(1760001300+1, 0)
+	bot.RunTick(ctx)
+	if bot.marketID != "0xround-1760001300" || bot.roundStartTime.Unix() != 1760001300 {
+		t.Fatalf("expected the second round, got %s starting %v", bot.marketID, bot.roundStartTime)
+	}
+	if bot.state != StateWatching {
+		t.Errorf("Expected state Watching after rollover, got %v", bot.state)
+	}
+	if bot.leg1Side != "" || bot.leg1Shares != 0 {
+		t.Errorf("Expected a fresh cycle, got leg 1 %s x %.2f", bot.leg1Side, bot.leg1Shares)
+	}
+}
+
+// subscriptionLog is a ticker source recording the markets it follows
+type subscriptionLog struct {
+	markets []string
+}
+
+func (s *subscriptionLog) Tickers() <-chan *exchange.Ticker { return nil }
+
+func (s *subscriptionLog) Subscribe(m exchange.Market) {
+	s.markets = append(s.markets, m.ID)
+}
+
+func (s *subscriptionLog) Unsubscribe(m exchange.Market) {
+	s.markets = slices.DeleteFunc(s.markets, func(id string) bool { return id == m.ID })
+}
+
+func TestBotBooksFillsCancelledAtRollover(t *testing.T) {
+	ctx := context.Background()
+	var lookups int32
+	cfg := config.DefaultConfig()
+	cfg.MovePct = 0.10
+	cfg.SumTarget = 0.90
+	cfg.Leg1OrderType = "GTC"
+	cfg.FillTimeout = time.Hour // Still resting when the round ends
+
+	mockExc := exchange.NewMockExchange()
+	mockExc.Time = time.Unix(1760000400+30, 0)
+	mockExc.Depth = 12
+	bot := NewBot(cfg, mockExc)
+	streams := &subscriptionLog{}
+	bot.SetTickerSource(streams)
+	bot.SetRoundScheduler(NewRoundScheduler(RoundSchedule{SlugPrefix: "btc-updown-15m", Duration: 15 * time.Minute},
+		newFakeGamma(t, 1760000400, 2, &lookups)))
+
+	mockExc.SetPrice(0.50, 0.50)
+	bot.RunTick(ctx)
+	mockExc.AdvanceTime(3 * time.Second)
+	bot.RunTick(ctx)
+	mockExc.AdvanceTime(1 * time.Second)
+	mockExc.SetPrice(0.40, 0.55)
+	bot.RunTick(ctx)
+	if bot.state != StateLeg1Pending {
+		t.Fatalf("Expected state Leg1Pending, got %v", bot.state)
+	}
+
+	// 12 of the 20 shares filled before the round ended
+	mockExc.Time = time.Unix(1760001300+1, 0)
+	bot.RunTick(ctx)
+	if bot.marketID != "0xround-1760001300" {
+		t.Fatalf("expected the second round, got %s", bot.marketID)
+	}
+	cycles := bot.Cycles()
+	if len(cycles) != 1 || cycles[0].MarketID != "0xround-1760000400" ||
+		cycles[0].Shares[exchange.SideUp] != exchange.AmountFromFloat(12) {
+		t.Errorf("Expected the partial fill booked in a cycle of the first round, got %+v", cycles)
+	}
+	if pos := bot.Portfolio().Position("0xround-1760000400", exchange.SideUp); pos.Shares != exchange.AmountFromFloat(12) {
+		t.Errorf("Expected 12 shares in the portfolio, got %s", pos.Shares)
+	}
+
+	// Only the running round stays subscribed
+	if !slices.Equal(streams.markets, []string{"0xround-1760001300"}) {
+		t.Errorf("Expected only the second round subscribed, got %v", streams.markets)
+	}
+}
+
+func TestBotBacksOffFailedRoundLookups(t *testing.T) {
+	ctx := context.Background()
+	var lookups int32
+	cfg := config.DefaultConfig()
+
+	// No round is listed at all
+	mockExc := exchange.NewMockExchange()
+	mockExc.Time = time.Unix(1760000400+30, 0)
+	bot := NewBot(cfg, mockExc)
+	bot.SetRoundScheduler(NewRoundScheduler(RoundSchedule{SlugPrefix: "btc-updown-15m", Duration: 15 * time.Minute},
+		newFakeGamma(t, 0, 0, &lookups)))
+
+	for i := 0; i < 5; i++ {
+		bot.RunTick(ctx)
+	}
+	if got := atomic.LoadInt32(&lookups); got != 1 {
+		t.Fatalf("expected a single lookup while backing off, got %d", got)
+	}
+
+	// Retried once the delay passed, then waiting twice as long
+	mockExc.AdvanceTime(roundRetryMin)
+	bot.RunTick(ctx)
+	mockExc.AdvanceTime(roundRetryMin)
+	bot.RunTick(ctx)
+	if got := atomic.LoadInt32(&lookups); got != 2 {
+		t.Errorf("expected a second lookup after the backoff, got %d", got)
+	}
+}
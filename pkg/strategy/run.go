@@ -0,0 +1,104 @@
+package strategy
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"poly/pkg/exchange"
+)
+
+// SetTickerSource makes Run react to pushed tickers instead of polling GetTicker
+func (b *Bot) SetTickerSource(src exchange.TickerSource) {
+	b.tickerSource = src
+}
+
+// SetUserEventSource makes Run advance pending orders on pushed fills
+func (b *Bot) SetUserEventSource(src exchange.UserEventSource) {
+	b.eventSource = src
+}
+
+// Errors reports the errors hit while running; errors are dropped when nobody reads them
+func (b *Bot) Errors() <-chan error {
+	return b.errs
+}
+
+// reportError logs an error and publishes it on the Errors channel
+func (b *Bot) reportError(err error) {
+	log.Printf("Error: %v", err)
+	select {
+	case b.errs <- err:
+	default:
+	}
+}
+
+// withTimeout bounds a single exchange call; d <= 0 leaves it bounded by ctx alone
+func (b *Bot) withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// Run drives the bot until ctx is cancelled. Without a ticker source it polls
+// GetTicker every PollInterval; with one it reacts to each pushed ticker and only
+// polls to keep pending orders moving when no events arrive. It returns early with
+// the error that halted the bot, e.g. rejected API credentials.
+func (b *Bot) Run(ctx context.Context) error {
+	interval := b.cfg.PollInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	poll := time.NewTicker(interval)
+	defer poll.Stop()
+
+	// A nil channel never delivers, which disables the corresponding case
+	var tickers <-chan *exchange.Ticker
+	if b.tickerSource != nil {
+		tickers = b.tickerSource.Tickers()
+	}
+	var events <-chan *exchange.UserEvent
+	if b.eventSource != nil {
+		events = b.eventSource.UserEvents()
+	}
+
+	// Subscribe the streams to the first round right away
+	b.updateRound(ctx, b.exchange.CurrentTime())
+
+	for {
+		if b.halted != nil {
+			return b.halted
+		}
+		select {
+		case <-ctx.Done():
+			log.Println("Bot stopped")
+			return ctx.Err()
+		case <-poll.C:
+			if tickers == nil {
+				b.RunTick(ctx)
+				continue
+			}
+			now := b.exchange.CurrentTime()
+			b.updateRound(ctx, now)
+			b.bookTrades(ctx)
+			b.reconcileSettlements(ctx)
+			b.redeemResolved(ctx, now)
+			if b.state == StateLeg1Pending || b.state == StateLeg2Pending {
+				b.pollPendingOrder(ctx)
+			}
+		case t, ok := <-tickers:
+			if !ok {
+				log.Println("Ticker source closed, falling back to polling")
+				tickers = nil
+				continue
+			}
+			b.HandleTicker(ctx, t)
+		case ev, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			b.HandleUserEvent(ctx, ev)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"poly/pkg/config"
+	"poly/pkg/exchange"
+)
+
+// tickerChan is a TickerSource fed by the test
+type tickerChan chan *exchange.Ticker
+
+func (c tickerChan) Tickers() <-chan *exchange.Ticker { return c }
+
+// failingExchange cannot serve prices
+type failingExchange struct {
+	*exchange.MockExchange
+}
+
+func (f *failingExchange) GetTicker(ctx context.Context, marketID string) (*exchange.Ticker, error) {
+	return nil, errors.New("book unavailable")
+}
+
+func TestRunReactsToPushedTickers(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.MovePct = 0.10
+	cfg.SumTarget = 0.90         // No immediate hedge
+	cfg.PollInterval = time.Hour // Only pushed tickers drive the bot
+
+	mockExc := exchange.NewMockExchange()
+	mockExc.SetPrice(0.40, 0.55) // What the FAK entry will hit
+	start := mockExc.CurrentTime()
+
+	bot := NewBot(cfg, mockExc)
+	src := make(tickerChan)
+	bot.SetTickerSource(src)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- bot.Run(ctx) }()
+
+	src <- &exchange.Ticker{MarketID: "mock-market", PriceUp: 0.50, PriceDown: 0.50, Timestamp: start}
+	src <- &exchange.Ticker{MarketID: "mock-market", PriceUp: 0.50, PriceDown: 0.50, Timestamp: start.Add(3 * time.Second)}
+	src <- &exchange.Ticker{MarketID: "mock-market", PriceUp: 0.40, PriceDown: 0.55, Timestamp: start.Add(4 * time.Second)}
+	cancel()
+
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Run returned %v, want context.Canceled", err)
+	}
+	if bot.state != StateLeg1Bought {
+		t.Fatalf("Expected state Leg1Bought, got %v", bot.state)
+	}
+	if bot.leg1Side != exchange.SideUp || bot.leg1EntryPrice != exchange.AmountFromFloat(0.40) {
+		t.Errorf("Expected UP entry at 0.40, got %s @ %s", bot.leg1Side, bot.leg1EntryPrice)
+	}
+}
+
+func TestRunReportsErrors(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.PollInterval = time.Millisecond
+
+	bot := NewBot(cfg, &failingExchange{exchange.NewMockExchange()})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- bot.Run(ctx) }()
+
+	select {
+	case err := <-bot.Errors():
+		if err == nil {
+			t.Error("expected a ticker error")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no error reported")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not stop on cancel")
+	}
+}
+
+// hangingExchange never answers a ticker request before the caller gives up
+type hangingExchange struct {
+	*exchange.MockExchange
+}
+
+func (h *hangingExchange) GetTicker(ctx context.Context, marketID string) (*exchange.Ticker, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestRequestTimeout(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RequestTimeout = 10 * time.Millisecond
+
+	bot := NewBot(cfg, &hangingExchange{exchange.NewMockExchange()})
+	done := make(chan struct{})
+	go func() {
+		bot.RunTick(context.Background())
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("RunTick ignored the request timeout")
+	}
+	select {
+	case err := <-bot.Errors():
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected a deadline error, got %v", err)
+		}
+	default:
+		t.Error("timeout not reported")
+	}
+}
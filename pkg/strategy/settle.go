@@ -0,0 +1,264 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"poly/pkg/exchange"
+	"poly/pkg/settlement"
+)
+
+// receiptChecker looks up the outcome of a sent transaction without waiting for it
+type receiptChecker interface {
+	// Receipt returns settlement.ErrPending until the transaction is mined, and
+	// settlement.ErrReverted when it failed
+	Receipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
+// Merger merges pairs of UP and DOWN shares back into USDC on chain, e.g. a settlement.Settler
+type Merger interface {
+	receiptChecker
+	SendMerge(ctx context.Context, marketID string, amount exchange.Amount, negRisk bool) (*types.Transaction, error)
+}
+
+// Redeemer cashes in the shares of resolved markets on chain, e.g. a settlement.Settler
+type Redeemer interface {
+	// Resolution returns settlement.ErrUnresolved until the market is resolved
+	Resolution(ctx context.Context, marketID string) (*settlement.Resolution, error)
+	receiptChecker
+	SendRedeem(ctx context.Context, marketID string, up, down exchange.Amount, negRisk bool) (*types.Transaction, error)
+}
+
+// pendingTx is a settlement transaction sent but not booked yet. Its effect is only
+// booked once it is mined, however long that takes.
+type pendingTx struct {
+	what     string // For the logs, e.g. "merge of 20 pairs of 0x.."
+	hash     common.Hash
+	marketID string
+	pairs    exchange.Amount // Pairs merged, 0 for other transactions
+	sent     time.Time
+	overdue  bool // Still not mined after SettleTimeout, reported once
+	receipts receiptChecker
+	mined    func() // Books the effect of the transaction
+}
+
+// SetMerger makes the bot merge every completed hedge right away, freeing the
+// collateral for the next cycle instead of waiting for the market to resolve
+func (b *Bot) SetMerger(m Merger) {
+	b.merger = m
+}
+
+// mergeHedged sends the merge of the pairs held in the market of the completed cycle;
+// later ticks book it once mined (see reconcileSettlements). The pairs are the exact
+// holdings, not the float leg sizes, so the merge never exceeds the balance on chain.
+// A failed merge leaves the pairs held: they still pay out at resolution.
+func (b *Bot) mergeHedged(ctx context.Context) {
+	if b.merger == nil {
+		return
+	}
+	marketID := b.marketID
+	up := b.portfolio.Position(marketID, exchange.SideUp).Shares
+	down := b.portfolio.Position(marketID, exchange.SideDown).Shares
+	pairs := min(up, down).Sub(b.mergesInFlight(marketID))
+	if pairs <= 0 {
+		return
+	}
+
+	callCtx, cancel := b.withTimeout(ctx, b.cfg.RequestTimeout)
+	info, err := b.exchange.GetMarketInfo(callCtx, marketID)
+	cancel()
+	if err != nil {
+		b.handleError(fmt.Errorf("looking up market %s for the merge: %w", marketID, err))
+		return
+	}
+
+	callCtx, cancel = b.withTimeout(ctx, b.cfg.RequestTimeout)
+	tx, err := b.merger.SendMerge(callCtx, marketID, pairs, info.NegRisk)
+	cancel()
+	if err != nil {
+		log.Printf("WARNING: merging %s pairs failed, holding them until resolution: %v", pairs, err)
+		return
+	}
+
+	b.track(&pendingTx{
+		what:     fmt.Sprintf("merge of %s pairs of %s", pairs, marketID),
+		hash:     tx.Hash(),
+		marketID: marketID,
+		pairs:    pairs,
+		receipts: b.merger,
+		mined:    func() { b.bookMerge(marketID, pairs) },
+	})
+}
+
+// settling reports whether a settlement transaction of a market is in flight
+func (b *Bot) settling(marketID string) bool {
+	for _, p := range b.settlements {
+		if p.marketID == marketID {
+			return true
+		}
+	}
+	return false
+}
+
+// mergesInFlight returns the pairs of a market being merged
+func (b *Bot) mergesInFlight(marketID string) exchange.Amount {
+	var pairs exchange.Amount
+	for _, p := range b.settlements {
+		if p.marketID == marketID {
+			pairs = pairs.Add(p.pairs)
+		}
+	}
+	return pairs
+}
+
+// bookMerge accounts for mined merged pairs in the portfolio and the cycles holding them
+func (b *Bot) bookMerge(marketID string, pairs exchange.Amount) {
+	pairs = b.portfolio.Merge(marketID, pairs)
+	left := pairs
+	for _, c := range b.cycles {
+		if c.MarketID != marketID || c.Settled {
+			continue
+		}
+		if n := min(left, c.Shares[exchange.SideUp], c.Shares[exchange.SideDown]); n > 0 {
+			c.merge(n)
+			left = left.Sub(n)
+		}
+	}
+	log.Printf("Merged %s pairs of %s back into %s USDC", pairs, marketID, pairs)
+}
+
+// track starts following a sent settlement transaction
+func (b *Bot) track(p *pendingTx) {
+	p.sent = b.exchange.CurrentTime()
+	log.Printf("Sent the %s: %s", p.what, p.hash.Hex())
+	b.settlements = append(b.settlements, p)
+}
+
+// reconcileSettlements books the settlement transactions mined since the last tick.
+// Reverted ones are dropped; their shares stay held.
+func (b *Bot) reconcileSettlements(ctx context.Context) {
+	now := b.exchange.CurrentTime()
+	pending := b.settlements[:0]
+	for _, p := range b.settlements {
+		callCtx, cancel := b.withTimeout(ctx, b.cfg.RequestTimeout)
+		_, err := p.receipts.Receipt(callCtx, p.hash)
+		cancel()
+		switch {
+		case err == nil:
+			p.mined()
+			continue
+		case errors.Is(err, settlement.ErrReverted):
+			log.Printf("WARNING: the %s reverted, its shares stay held: %v", p.what, err)
+			continue
+		case errors.Is(err, settlement.ErrPending):
+			if !p.overdue && now.Sub(p.sent) > b.cfg.SettleTimeout {
+				log.Printf("WARNING: the %s is not mined after %v, still watching %s", p.what, b.cfg.SettleTimeout, p.hash.Hex())
+				p.overdue = true
+			}
+		default:
+			b.reportError(fmt.Errorf("checking the %s: %w", p.what, err))
+		}
+		pending = append(pending, p)
+	}
+	b.settlements = pending
+}
+
+// SetRedeemer makes the bot watch the markets it holds shares of, every
+// RedeemInterval, and redeem them once resolved
+func (b *Bot) SetRedeemer(r Redeemer) {
+	b.redeemer = r
+}
+
+// redeemResolved redeems the held markets that resolved since the last check
+func (b *Bot) redeemResolved(ctx context.Context, now time.Time) {
+	if b.redeemer == nil || now.Sub(b.lastRedeemCheck) < b.cfg.RedeemInterval {
+		return
+	}
+	b.lastRedeemCheck = now
+
+	for _, marketID := range b.heldMarkets() {
+		if !b.settling(marketID) {
+			b.redeemMarket(ctx, marketID)
+		}
+	}
+}
+
+// redeemMarket sends the redemption of the shares of a market if it is resolved;
+// later ticks book the payout against the cycles that bought them once it is mined
+func (b *Bot) redeemMarket(ctx context.Context, marketID string) {
+	callCtx, cancel := b.withTimeout(ctx, b.cfg.RequestTimeout)
+	resolution, err := b.redeemer.Resolution(callCtx, marketID)
+	cancel()
+	if errors.Is(err, settlement.ErrUnresolved) {
+		return
+	}
+	if err != nil {
+		b.reportError(fmt.Errorf("checking the resolution of %s: %w", marketID, err))
+		return
+	}
+
+	up := b.portfolio.Position(marketID, exchange.SideUp).Shares
+	down := b.portfolio.Position(marketID, exchange.SideDown).Shares
+	if up <= 0 && down <= 0 {
+		// The shares left the wallet some other way: the cycles get nothing for them
+		b.writeOff(marketID)
+		return
+	}
+
+	callCtx, cancel = b.withTimeout(ctx, b.cfg.RequestTimeout)
+	info, err := b.exchange.GetMarketInfo(callCtx, marketID)
+	cancel()
+	if err != nil {
+		b.handleError(fmt.Errorf("looking up market %s for the redemption: %w", marketID, err))
+		return
+	}
+
+	callCtx, cancel = b.withTimeout(ctx, b.cfg.RequestTimeout)
+	tx, err := b.redeemer.SendRedeem(callCtx, marketID, up, down, info.NegRisk)
+	cancel()
+	if err != nil {
+		log.Printf("WARNING: redeeming %s failed, retrying on the next check: %v", marketID, err)
+		return
+	}
+
+	b.track(&pendingTx{
+		what:     fmt.Sprintf("redemption of %s UP and %s DOWN shares of %s", up, down, marketID),
+		hash:     tx.Hash(),
+		marketID: marketID,
+		receipts: b.redeemer,
+		mined: func() {
+			b.portfolio.Redeem(marketID, resolution)
+			b.bookRedemption(marketID, resolution)
+		},
+	})
+}
+
+// bookRedemption settles the cycles of a resolved market at its payouts
+func (b *Bot) bookRedemption(marketID string, resolution *settlement.Resolution) {
+	for _, c := range b.cycles {
+		if c.MarketID != marketID || c.Settled {
+			continue
+		}
+		paid := c.redeem(resolution)
+		log.Printf("Cycle %d of %s redeemed for %s: paid %s in total for a cost of %s, P&L %s",
+			c.ID, marketID, paid, c.Payout, c.Cost, c.PnL())
+	}
+}
+
+// writeOff settles the cycles of a resolved market whose shares are no longer held
+func (b *Bot) writeOff(marketID string) {
+	for _, c := range b.cycles {
+		if c.MarketID != marketID || c.Settled {
+			continue
+		}
+		c.writeOff()
+		log.Printf("WARNING: cycle %d of %s settled without payout, its shares are no longer held: P&L %s",
+			c.ID, marketID, c.PnL())
+	}
+}
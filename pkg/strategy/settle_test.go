@@ -0,0 +1,232 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"poly/pkg/config"
+	"poly/pkg/exchange"
+	"poly/pkg/settlement"
+)
+
+// fakeMerger records merges instead of sending them. They are mined on the first
+// receipt check unless a status says otherwise.
+type fakeMerger struct {
+	err    error // Fails the sends
+	status error // Returned by receipt checks
+	merged []exchange.Amount
+}
+
+func (f *fakeMerger) SendMerge(ctx context.Context, marketID string, amount exchange.Amount, negRisk bool) (*types.Transaction, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.merged = append(f.merged, amount)
+	return types.NewTx(&types.LegacyTx{Nonce: uint64(len(f.merged))}), nil
+}
+
+func (f *fakeMerger) Receipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	if f.status != nil {
+		return nil, f.status
+	}
+	return &types.Receipt{Status: types.ReceiptStatusSuccessful, TxHash: txHash}, nil
+}
+
+func TestBotMergesHedgedPairs(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.DefaultConfig()
+	cfg.MovePct = 0.10
+	cfg.SumTarget = 0.96
+
+	mockExc := exchange.NewMockExchange()
+	bot := NewBot(cfg, mockExc)
+	merger := &fakeMerger{status: settlement.ErrPending}
+	bot.SetMerger(merger)
+
+	// Leg 1 at 0.40, hedged right away at 0.55
+	dump(ctx, bot, mockExc)
+	bot.RunTick(ctx)
+	if bot.state != StateDone {
+		t.Fatalf("Expected state Done, got %v", bot.state)
+	}
+	if len(merger.merged) != 1 || merger.merged[0] != exchange.AmountFromFloat(20) {
+		t.Fatalf("Expected the 20 pairs to be merged, got %v", merger.merged)
+	}
+
+	// Nothing is booked, nor merged again, until the merge is mined
+	bot.RunTick(ctx)
+	p := bot.Portfolio()
+	if up := p.Position(cfg.MarketID, exchange.SideUp); up.Shares != exchange.AmountFromFloat(20) {
+		t.Errorf("Expected the pairs to be held until the merge is mined, got %s UP", up.Shares)
+	}
+	if c := bot.Cycles()[0]; c.Settled {
+		t.Error("Expected the cycle to be unsettled until the merge is mined")
+	}
+	if len(merger.merged) != 1 {
+		t.Errorf("Expected a single merge in flight, got %v", merger.merged)
+	}
+
+	merger.status = nil
+	bot.RunTick(ctx)
+	if c := bot.Cycles()[0]; !c.Settled || c.PnL() != exchange.AmountFromFloat(1) {
+		t.Errorf("Expected the merge to settle the cycle with 1 of profit, got %+v", c)
+	}
+
+	// The pairs are gone and the 1 of profit is locked in
+	if up, down := p.Position(cfg.MarketID, exchange.SideUp), p.Position(cfg.MarketID, exchange.SideDown); up.Shares != 0 || down.Shares != 0 {
+		t.Errorf("Expected no shares left, got %s UP and %s DOWN", up.Shares, down.Shares)
+	}
+	if pnl := p.MarketPnL(cfg.MarketID, &exchange.Ticker{}); pnl.Realized != exchange.AmountFromFloat(1) {
+		t.Errorf("Expected 1 realized, got %s", pnl.Realized)
+	}
+
+	// Failed and reverted merges keep the pairs for resolution
+	for _, merger := range []*fakeMerger{
+		{err: errors.New("out of gas")},
+		{status: fmt.Errorf("%w: tx 0x01", settlement.ErrReverted)},
+	} {
+		mockExc = exchange.NewMockExchange()
+		bot = NewBot(cfg, mockExc)
+		bot.SetMerger(merger)
+		dump(ctx, bot, mockExc)
+		bot.RunTick(ctx)
+		bot.RunTick(ctx)
+		if up := bot.Portfolio().Position(cfg.MarketID, exchange.SideUp); up.Shares != exchange.AmountFromFloat(20) {
+			t.Errorf("Expected the pairs to be held after a failed merge, got %s UP", up.Shares)
+		}
+		if bot.Halted() != nil {
+			t.Error("A failed merge should not halt the bot")
+		}
+	}
+}
+
+// fakeRedeemer plays the oracle and the CTF: markets resolve when told to, and
+// redemptions are mined on the first receipt check unless a status says otherwise
+type fakeRedeemer struct {
+	resolved map[string]*settlement.Resolution
+	status   error // Returned by receipt checks
+	checks   int
+	redeemed [][2]exchange.Amount
+}
+
+func (f *fakeRedeemer) Resolution(ctx context.Context, marketID string) (*settlement.Resolution, error) {
+	f.checks++
+	if r, ok := f.resolved[marketID]; ok {
+		return r, nil
+	}
+	return nil, settlement.ErrUnresolved
+}
+
+func (f *fakeRedeemer) SendRedeem(ctx context.Context, marketID string, up, down exchange.Amount, negRisk bool) (*types.Transaction, error) {
+	f.redeemed = append(f.redeemed, [2]exchange.Amount{up, down})
+	return types.NewTx(&types.LegacyTx{Nonce: uint64(len(f.redeemed))}), nil
+}
+
+func (f *fakeRedeemer) Receipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	if f.status != nil {
+		return nil, f.status
+	}
+	return &types.Receipt{Status: types.ReceiptStatusSuccessful, TxHash: txHash}, nil
+}
+
+func TestBotRedeemsResolvedMarkets(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.DefaultConfig()
+	cfg.MovePct = 0.10
+	cfg.SumTarget = 0.90 // No hedge: leg 1 is left unhedged
+
+	mockExc := exchange.NewMockExchange()
+	bot := NewBot(cfg, mockExc)
+	redeemer := &fakeRedeemer{resolved: make(map[string]*settlement.Resolution)}
+	bot.SetRedeemer(redeemer)
+
+	// 20 UP bought at 0.40, then the round ends
+	dump(ctx, bot, mockExc)
+	if bot.state != StateLeg1Bought {
+		t.Fatalf("Expected state Leg1Bought, got %v", bot.state)
+	}
+	bot.ResetCycle()
+
+	// Unresolved markets are checked once per RedeemInterval
+	checks := redeemer.checks
+	mockExc.AdvanceTime(cfg.RedeemInterval)
+	bot.RunTick(ctx)
+	bot.RunTick(ctx)
+	if redeemer.checks != checks+1 || len(redeemer.redeemed) != 0 {
+		t.Fatalf("Expected one more check and no redemption, got %d checks and %v", redeemer.checks-checks, redeemer.redeemed)
+	}
+
+	// UP wins: the 20 shares pay 20 for a cost of 8
+	redeemer.resolved[cfg.MarketID] = &settlement.Resolution{Up: exchange.AmountFromFloat(1)}
+	redeemer.status = settlement.ErrPending
+	mockExc.AdvanceTime(cfg.RedeemInterval)
+	bot.RunTick(ctx)
+	if len(redeemer.redeemed) != 1 || redeemer.redeemed[0] != [2]exchange.Amount{exchange.AmountFromFloat(20), 0} {
+		t.Fatalf("Expected the 20 UP shares to be redeemed, got %v", redeemer.redeemed)
+	}
+
+	// Nothing is booked, nor redeemed again, until the redemption is mined
+	mockExc.AdvanceTime(cfg.RedeemInterval)
+	bot.RunTick(ctx)
+	if c := bot.Cycles()[0]; c.Settled || len(redeemer.redeemed) != 1 {
+		t.Fatalf("Expected a single redemption in flight and the cycle unsettled, got %v and %+v", redeemer.redeemed, c)
+	}
+	redeemer.status = nil
+	bot.RunTick(ctx)
+
+	cycles := bot.Cycles()
+	if len(cycles) != 1 {
+		t.Fatalf("Expected one cycle, got %d", len(cycles))
+	}
+	c := cycles[0]
+	if !c.Settled || c.Payout != exchange.AmountFromFloat(20) || c.PnL() != exchange.AmountFromFloat(12) {
+		t.Errorf("Expected the cycle settled with 12 of profit, got %+v", c)
+	}
+	if up := bot.Portfolio().Position(cfg.MarketID, exchange.SideUp); up.Shares != 0 || up.Realized != exchange.AmountFromFloat(12) {
+		t.Errorf("Expected the position closed with 12 realized, got %+v", up)
+	}
+
+	// Nothing is held any more: no further checks
+	checks = redeemer.checks
+	mockExc.AdvanceTime(time.Hour)
+	bot.RunTick(ctx)
+	if redeemer.checks != checks || len(redeemer.redeemed) != 1 {
+		t.Errorf("Expected settled markets to be left alone")
+	}
+}
+
+func TestBotWritesOffSharesNoLongerHeld(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.DefaultConfig()
+	cfg.MovePct = 0.10
+	cfg.SumTarget = 0.90 // No hedge: leg 1 is left unhedged
+
+	mockExc := exchange.NewMockExchange()
+	bot := NewBot(cfg, mockExc)
+	redeemer := &fakeRedeemer{resolved: make(map[string]*settlement.Resolution)}
+	bot.SetRedeemer(redeemer)
+
+	dump(ctx, bot, mockExc)
+	bot.ResetCycle()
+
+	// The 20 UP shares are sold outside the bot before UP wins
+	bot.Portfolio().Apply(&exchange.Trade{ID: "elsewhere", MarketID: cfg.MarketID, OrderID: "0xmanual", Side: exchange.SideUp,
+		Direction: exchange.DirectionSell, Price: 0.5, Size: 20, Status: exchange.TradeConfirmed})
+	redeemer.resolved[cfg.MarketID] = &settlement.Resolution{Up: exchange.AmountFromFloat(1)}
+	mockExc.AdvanceTime(cfg.RedeemInterval)
+	bot.RunTick(ctx)
+
+	if len(redeemer.redeemed) != 0 {
+		t.Errorf("Expected nothing to be redeemed, got %v", redeemer.redeemed)
+	}
+	c := bot.Cycles()[0]
+	if !c.Settled || c.Payout != 0 || c.Shares[exchange.SideUp] != 0 {
+		t.Errorf("Expected the cycle settled without payout, got %+v", c)
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"poly/pkg/config"
+	"poly/pkg/exchange"
+	"poly/pkg/settlement"
+	"poly/pkg/strategy"
+)
+
+// runLive 以常驻服务方式运行实盘机器人, 收到 SIGINT/SIGTERM 后退出
+//
+// 用法: go run . run -market <condition id> [-poll] [-sigtype 0|1|2]
+// 循环市场用 -series btc-updown-15m [-round 15m] 代替 -market, 每轮结束自动切换到下一轮
+// 凭证从环境变量 POLY_API_KEY / POLY_API_SECRET / POLY_PASSPHRASE / POLY_FUNDER 读取, 签名方式见 loadSigner
+// 加 -rpc <url> (或 POLY_RPC_URL) 后, 每次对冲完成即把成对的 YES+NO 链上合并回 USDC,
+// 并定期检查持仓市场是否已结算, 结算后赎回剩余股份; 订单按链上 nonce 签名 (见 invalidate 命令)
+func runLive(args []string) {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	marketID := fs.String("market", "", "要交易的市场 (condition) ID")
+	tokenUp := fs.String("up", "", "UP/YES 结果的 token ID (可选, 见 markets 命令)")
+	tokenDown := fs.String("down", "", "DOWN/NO 结果的 token ID (可选)")
+	series := fs.String("series", "", "循环市场的 slug 前缀, 如 btc-updown-15m")
+	roundLen := fs.Duration("round", 15*time.Minute, "循环市场每轮时长")
+	poll := fs.Bool("poll", false, "按 PollInterval 轮询 REST 行情, 不使用 WebSocket")
+	pollInterval := fs.Duration("interval", time.Second, "轮询间隔")
+	sigType := fs.Uint("sigtype", 0, "签名类型: 0=EOA, 1=POLY_PROXY, 2=POLY_GNOSIS_SAFE")
+	rpcURL := fs.String("rpc", os.Getenv("POLY_RPC_URL"), "Polygon RPC 地址; 设置后对冲完成即链上合并 YES+NO 换回 USDC, 市场结算后赎回剩余持仓")
+	fs.Parse(args)
+
+	if *marketID == "" && *series == "" {
+		log.Fatal("缺少 -market 或 -series")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	s, err := loadSigner(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	client := exchange.NewPolymarketClientWithSigner(
+		os.Getenv("POLY_API_KEY"),
+		os.Getenv("POLY_API_SECRET"),
+		os.Getenv("POLY_PASSPHRASE"),
+		s,
+		os.Getenv("POLY_FUNDER"),
+	)
+	client.SignatureType = exchange.SignatureType(*sigType)
+	// 未设置 POLY_FUNDER 时按签名类型推导代理钱包/Safe 地址 (CREATE2), 设置了则校验
+	if os.Getenv("POLY_FUNDER") == "" {
+		client.Funder, err = client.DeriveFunder()
+	} else {
+		err = client.VerifyFunder()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *marketID != "" && *tokenUp != "" && *tokenDown != "" {
+		client.RegisterMarket(exchange.Market{ID: *marketID, TokenUp: *tokenUp, TokenDown: *tokenDown})
+	}
+
+	var markets []exchange.Market
+	if *marketID != "" {
+		market, err := client.Market(ctx, *marketID)
+		if err != nil {
+			log.Fatalf("查询市场失败: %v", err)
+		}
+		markets = append(markets, *market)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.MarketID = *marketID
+	cfg.TokenIDUp = *tokenUp
+	cfg.TokenIDDown = *tokenDown
+	cfg.PollInterval = *pollInterval
+
+	bot := strategy.NewBot(cfg, client)
+	if *series != "" {
+		// 通过 Gamma API 按 slug 查找当前与下一轮市场
+		schedule := strategy.RoundSchedule{SlugPrefix: *series, Duration: *roundLen}
+		bot.SetRoundScheduler(strategy.NewRoundScheduler(schedule, exchange.NewGammaClient()))
+	}
+	if *rpcURL != "" {
+		// 合并交易由私钥发送, 经 Funder (代理钱包/Safe) 执行
+		chain, err := ethclient.DialContext(ctx, *rpcURL)
+		if err != nil {
+			log.Fatalf("连接 RPC 失败: %v", err)
+		}
+		defer chain.Close()
+		settler := settlement.NewSettler(chain, client.Signer)
+		settler.Funder = client.Funder
+		settler.SignatureType = client.SignatureType
+		bot.SetMerger(settler)
+		bot.SetRedeemer(settler)
+		// 订单以链上当前 nonce 签名, 并定期刷新以跟上 invalidate
+		if err := loadNonces(ctx, settler, client); err != nil {
+			log.Fatalf("读取订单 nonce 失败: %v", err)
+		}
+		go watchNonces(ctx, settler, client, nonceRefresh)
+	} else {
+		log.Printf("警告: 未设置 -rpc, 无法读取链上 nonce, 订单按 nonce 0 签名; 若 nonce 已递增, 订单将被拒绝")
+	}
+	if !*poll {
+		// 行情与成交通过 WebSocket 推送, 断线自动重连
+		books := exchange.NewMarketStream(exchange.MarketChannelURL, markets...)
+		fills := exchange.NewUserStream(exchange.UserChannelURL, exchange.APICredentials{
+			APIKey:     client.APIKey,
+			Secret:     client.APISecret,
+			Passphrase: client.Passphrase,
+		}, markets...)
+		go books.Run(ctx)
+		go fills.Run(ctx)
+		bot.SetTickerSource(books)
+		bot.SetUserEventSource(fills)
+	}
+
+	log.Printf("机器人启动, 市场 %s%s", *marketID, *series)
+	if err := bot.Run(ctx); !errors.Is(err, context.Canceled) {
+		log.Printf("机器人停止: %v", err)
+	}
+
+	// 退出前撤掉所有挂单; ctx 可能已取消, 另给撤单一个期限
+	cleanup, cancel := context.WithTimeout(context.Background(), cfg.RequestTimeout)
+	defer cancel()
+	if err := client.CancelAll(cleanup); err != nil {
+		log.Printf("撤单失败: %v", err)
+	}
+	log.Println("已退出")
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"poly/pkg/exchange"
+	"poly/pkg/settlement"
+)
+
+// runSetup 推导 Funder 地址, 检查并补齐交易所合约所需的 USDC 与 CTF 授权
+//
+// 用法: go run . setup -rpc <url> [-sigtype 0|1|2] [-check]
+// 签名方式见 loadSigner; 设置了 POLY_FUNDER 时校验其与推导结果一致
+func runSetup(args []string) {
+	fs := flag.NewFlagSet("setup", flag.ExitOnError)
+	rpcURL := fs.String("rpc", os.Getenv("POLY_RPC_URL"), "Polygon RPC 地址")
+	sigType := fs.Uint("sigtype", 0, "签名类型: 0=EOA, 1=POLY_PROXY, 2=POLY_GNOSIS_SAFE")
+	check := fs.Bool("check", false, "只检查授权, 不发送交易")
+	timeout := fs.Duration("timeout", 5*time.Minute, "等待授权交易上链的期限")
+	fs.Parse(args)
+
+	if *rpcURL == "" {
+		log.Fatal("缺少 -rpc 或 POLY_RPC_URL")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	s, err := loadSigner(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	funder, err := exchange.FunderAddress(s.Address(), exchange.SignatureType(*sigType))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if configured := os.Getenv("POLY_FUNDER"); configured != "" && common.HexToAddress(configured) != funder {
+		log.Fatalf("POLY_FUNDER %s 与推导的 %s 地址 %s 不一致, 检查 -sigtype", configured, exchange.SignatureType(*sigType), funder.Hex())
+	}
+	fmt.Printf("签名地址: %s\n", s.Address().Hex())
+	fmt.Printf("Funder (%s): %s\n", exchange.SignatureType(*sigType), funder.Hex())
+
+	chain, err := ethclient.DialContext(ctx, *rpcURL)
+	if err != nil {
+		log.Fatalf("连接 RPC 失败: %v", err)
+	}
+	defer chain.Close()
+	settler := settlement.NewSettler(chain, s)
+	settler.Funder = funder
+	settler.SignatureType = exchange.SignatureType(*sigType)
+
+	approvals, err := settler.Approvals(ctx)
+	if err != nil {
+		log.Fatalf("查询授权失败: %v", err)
+	}
+	missing := 0
+	for _, a := range approvals {
+		status := "已授权"
+		if !a.Granted {
+			status = "缺失"
+			missing++
+		}
+		fmt.Printf("  %-30s %s\n", a.Name, status)
+	}
+	if missing == 0 {
+		fmt.Println("授权齐全, 可以交易")
+		return
+	}
+	if *check {
+		fmt.Printf("缺少 %d 项授权, 去掉 -check 以发送授权交易\n", missing)
+		os.Exit(1)
+	}
+
+	// 授权交易由签名地址发送 (需要少量 POL 支付 gas), 经代理钱包/Safe 执行
+	granted, err := settler.Approve(ctx)
+	for _, a := range granted {
+		fmt.Printf("  %-30s 已授权\n", a.Name)
+	}
+	if err != nil {
+		log.Fatalf("授权失败: %v", err)
+	}
+	fmt.Println("授权齐全, 可以交易")
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"poly/pkg/signer"
+)
+
+// loadSigner 按环境变量选择签名方式, 优先级从高到低:
+//
+//	POLY_SIGNER_URL [POLY_SIGNER_ADDRESS]         远程签名服务 (web3signer), 私钥不进入本进程
+//	POLY_KEYSTORE + POLY_KEYSTORE_PASSWORD(_FILE) 加密的 keystore 文件
+//	POLY_MNEMONIC [POLY_MNEMONIC_PASSPHRASE] [POLY_HD_PATH] BIP-39 助记词, 默认路径 m/44'/60'/0'/0/0
+//	POLY_PRIVATE_KEY                              明文私钥 (不推荐)
+func loadSigner(ctx context.Context) (signer.Signer, error) {
+	if url := os.Getenv("POLY_SIGNER_URL"); url != "" {
+		return signer.NewRemoteSigner(ctx, url, common.HexToAddress(os.Getenv("POLY_SIGNER_ADDRESS")))
+	}
+
+	if path := os.Getenv("POLY_KEYSTORE"); path != "" {
+		password := os.Getenv("POLY_KEYSTORE_PASSWORD")
+		if file := os.Getenv("POLY_KEYSTORE_PASSWORD_FILE"); file != "" {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			password = strings.TrimRight(string(data), "\r\n")
+		}
+		return signer.FromKeystore(path, password)
+	}
+
+	if mnemonic := os.Getenv("POLY_MNEMONIC"); mnemonic != "" {
+		path := os.Getenv("POLY_HD_PATH")
+		if path == "" {
+			path = signer.DefaultPath
+		}
+		return signer.FromMnemonic(mnemonic, os.Getenv("POLY_MNEMONIC_PASSPHRASE"), path)
+	}
+
+	if key := os.Getenv("POLY_PRIVATE_KEY"); key != "" {
+		return signer.FromHex(key)
+	}
+	return nil, errors.New("未配置签名方式: 设置 POLY_SIGNER_URL, POLY_KEYSTORE, POLY_MNEMONIC 或 POLY_PRIVATE_KEY")
+}